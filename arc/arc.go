@@ -0,0 +1,619 @@
+// Package arc implements the Adaptive Replacement Cache (ARC) algorithm.
+//
+// ARC balances recency and frequency by keeping two LRU lists of cached
+// entries, T1 (seen once) and T2 (seen more than once), alongside two
+// key-only ghost LRU lists, B1 and B2, that remember recently evicted keys
+// from T1 and T2 respectively. A target size p for T1 adapts on every ghost
+// hit: a hit in B1 grows p (favoring recency), a hit in B2 shrinks it
+// (favoring frequency), so the cache self-tunes to the workload without any
+// manual parameters.
+package arc
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/kolobok-kelbek/cacherno/internal/cacheutil"
+)
+
+// ErrSizeExceedCapacity is returned by AddWithTTL when a value's size, as
+// reported by a WithSizer func, exceeds the cache's WithMaxBytes budget on
+// its own.
+var ErrSizeExceedCapacity = errors.New("value size exceeds max bytes capacity")
+
+// EvictReason identifies why an entry left the cache, for an EventEvict
+// Event or a WithOnEvict callback.
+type EvictReason = cacheutil.EvictReason
+
+const (
+	ReasonCapacity = cacheutil.ReasonCapacity
+	ReasonTTL      = cacheutil.ReasonTTL
+	ReasonManual   = cacheutil.ReasonManual
+	// ReasonReplace marks an eviction made by ARC's own REPLACE(x)
+	// procedure, which demotes a T1 or T2 entry into its ghost list to
+	// admit a new or promoted one.
+	ReasonReplace = cacheutil.ReasonReplace
+)
+
+// EventType identifies the kind of change an Event reports.
+type EventType = cacheutil.EventType
+
+const (
+	EventInsert = cacheutil.EventInsert
+	EventEvict  = cacheutil.EventEvict
+	EventRemove = cacheutil.EventRemove
+)
+
+type location uint8
+
+const (
+	locT1 location = iota
+	locT2
+	locB1
+	locB2
+)
+
+type entry[K comparable, V any] struct {
+	key   K
+	value V
+	loc   location
+	size  int64
+
+	expiresAt time.Time
+
+	prev *entry[K, V]
+	next *entry[K, V]
+}
+
+func (e *entry[K, V]) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
+}
+
+type Cache[K comparable, V any] struct {
+	data map[K]*entry[K, V]
+	lock sync.RWMutex
+
+	capacity uint
+	p        uint
+
+	t1Head, t1Tail *entry[K, V]
+	t1Len          uint
+
+	t2Head, t2Tail *entry[K, V]
+	t2Len          uint
+
+	b1Head, b1Tail *entry[K, V]
+	b1Len          uint
+
+	b2Head, b2Tail *entry[K, V]
+	b2Len          uint
+
+	defaultTTL time.Duration
+
+	janitorInterval time.Duration
+	janitor         cacheutil.Janitor
+
+	sizer    func(V) int64
+	maxBytes int64
+	bytes    int64
+
+	events cacheutil.Bus[K, V]
+}
+
+// Option configures optional behavior on a Cache constructed via
+// NewCacheWithOptions.
+type Option[K comparable, V any] func(*Cache[K, V])
+
+// WithDefaultTTL sets the expiration applied to entries added via Add (and
+// via AddWithTTL when passed a non-positive ttl). Entries never expire by
+// default.
+func WithDefaultTTL[K comparable, V any](d time.Duration) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.defaultTTL = d
+	}
+}
+
+// WithJanitor starts a background goroutine that sweeps expired entries
+// every interval. Call Close to stop it.
+func WithJanitor[K comparable, V any](interval time.Duration) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.janitorInterval = interval
+	}
+}
+
+// WithSizer enables byte-size accounting: f reports the size of a value,
+// and that size is added to Bytes() and tracked per entry. Pair with
+// WithMaxBytes to cap the cache by total size in addition to entry count.
+func WithSizer[K comparable, V any](f func(V) int64) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.sizer = f
+	}
+}
+
+// WithMaxBytes caps the cache's total tracked size, evicting entries (in
+// the cache's normal eviction order) after each Add until the budget is
+// met. Has no effect without WithSizer.
+func WithMaxBytes[K comparable, V any](n int64) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.maxBytes = n
+	}
+}
+
+// WithOnEvict registers a callback invoked whenever an entry is evicted.
+// It runs after the internal lock has been released, so it may safely call
+// back into the cache.
+func WithOnEvict[K comparable, V any](f func(key K, value V, reason EvictReason)) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.events.SetOnEvict(f)
+	}
+}
+
+func NewCache[K comparable, V any](capacity uint) (*Cache[K, V], error) {
+	return NewCacheWithOptions[K, V](capacity)
+}
+
+func NewCacheWithOptions[K comparable, V any](capacity uint, opts ...Option[K, V]) (*Cache[K, V], error) {
+	if capacity <= 0 {
+		return nil, errors.New("must provide a positive size")
+	}
+
+	c := &Cache[K, V]{
+		data:     make(map[K]*entry[K, V], capacity),
+		capacity: capacity,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.janitorInterval > 0 {
+		c.janitor.Start(c.janitorInterval, c.sweepExpired)
+	}
+
+	return c, nil
+}
+
+func (c *Cache[K, V]) Add(key K, value V) (evicted bool, rewritten bool) {
+	evicted, rewritten, _ = c.addWithTTL(key, value, c.defaultTTL)
+	return
+}
+
+// AddWithTTL adds key with an expiration ttl after which it is treated as a
+// miss and transparently removed. A non-positive ttl falls back to the
+// cache's default TTL, if any. It returns ErrSizeExceedCapacity, without
+// adding the entry, when a WithSizer/WithMaxBytes budget is configured and
+// value alone exceeds it.
+func (c *Cache[K, V]) AddWithTTL(key K, value V, ttl time.Duration) (evicted bool, rewritten bool, err error) {
+	return c.addWithTTL(key, value, ttl)
+}
+
+func (c *Cache[K, V]) addWithTTL(key K, value V, ttl time.Duration) (evicted bool, rewritten bool, err error) {
+	var events []cacheutil.Event[K, V]
+	func() {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		var size int64
+		if c.sizer != nil {
+			size = c.sizer(value)
+			if c.maxBytes > 0 && size > c.maxBytes {
+				err = ErrSizeExceedCapacity
+				return
+			}
+		}
+
+		if ttl <= 0 {
+			ttl = c.defaultTTL
+		}
+		var expiresAt time.Time
+		if ttl > 0 {
+			expiresAt = time.Now().Add(ttl)
+		}
+
+		if node, has := c.data[key]; has {
+			switch node.loc {
+			case locT1:
+				c.bytes += size - node.size
+				node.value = value
+				node.size = size
+				node.expiresAt = expiresAt
+				c.unlink(&c.t1Head, &c.t1Tail, node)
+				c.t1Len--
+				node.loc = locT2
+				c.pushFront(&c.t2Head, &c.t2Tail, node)
+				c.t2Len++
+				rewritten = true
+				events = append(events, cacheutil.Event[K, V]{Type: EventInsert, Key: key, Value: value})
+				evicted = c.evictOverBudget(&events)
+				return
+
+			case locT2:
+				c.bytes += size - node.size
+				node.value = value
+				node.size = size
+				node.expiresAt = expiresAt
+				c.unlink(&c.t2Head, &c.t2Tail, node)
+				c.pushFront(&c.t2Head, &c.t2Tail, node)
+				rewritten = true
+				events = append(events, cacheutil.Event[K, V]{Type: EventInsert, Key: key, Value: value})
+				evicted = c.evictOverBudget(&events)
+				return
+
+			case locB1:
+				delta := uint(1)
+				if c.b1Len > 0 && c.b2Len > c.b1Len {
+					delta = c.b2Len / c.b1Len
+				}
+				c.p += delta
+				if c.p > c.capacity {
+					c.p = c.capacity
+				}
+				if replaced := c.replace(false); replaced != nil {
+					events = append(events, cacheutil.Event[K, V]{Type: EventEvict, Key: replaced.key, Value: replaced.value, Reason: ReasonReplace})
+				}
+
+				c.unlink(&c.b1Head, &c.b1Tail, node)
+				c.b1Len--
+				node.value = value
+				node.size = size
+				node.expiresAt = expiresAt
+				node.loc = locT2
+				c.pushFront(&c.t2Head, &c.t2Tail, node)
+				c.t2Len++
+				c.bytes += size
+				evicted = true
+				events = append(events, cacheutil.Event[K, V]{Type: EventInsert, Key: key, Value: value})
+				c.evictOverBudget(&events)
+				return
+
+			case locB2:
+				delta := uint(1)
+				if c.b2Len > 0 && c.b1Len > c.b2Len {
+					delta = c.b1Len / c.b2Len
+				}
+				if delta > c.p {
+					c.p = 0
+				} else {
+					c.p -= delta
+				}
+				if replaced := c.replace(true); replaced != nil {
+					events = append(events, cacheutil.Event[K, V]{Type: EventEvict, Key: replaced.key, Value: replaced.value, Reason: ReasonReplace})
+				}
+
+				c.unlink(&c.b2Head, &c.b2Tail, node)
+				c.b2Len--
+				node.value = value
+				node.size = size
+				node.expiresAt = expiresAt
+				node.loc = locT2
+				c.pushFront(&c.t2Head, &c.t2Tail, node)
+				c.t2Len++
+				c.bytes += size
+				evicted = true
+				events = append(events, cacheutil.Event[K, V]{Type: EventInsert, Key: key, Value: value})
+				c.evictOverBudget(&events)
+				return
+			}
+		}
+
+		if c.makeRoomForNewKey(&events) {
+			evicted = true
+		}
+
+		node := &entry[K, V]{key: key, value: value, loc: locT1, size: size, expiresAt: expiresAt}
+		c.data[key] = node
+		c.pushFront(&c.t1Head, &c.t1Tail, node)
+		c.t1Len++
+		c.bytes += size
+		events = append(events, cacheutil.Event[K, V]{Type: EventInsert, Key: key, Value: value})
+
+		if c.evictOverBudget(&events) {
+			evicted = true
+		}
+	}()
+
+	c.events.DispatchAll(events)
+	return evicted, rewritten, err
+}
+
+// evictOverBudget evicts resident entries (via replace's normal T1/T2 LRU
+// selection) while the cache is over its WithMaxBytes budget, appending an
+// EventEvict for each to events and returning whether it evicted anything.
+func (c *Cache[K, V]) evictOverBudget(events *[]cacheutil.Event[K, V]) bool {
+	if c.maxBytes <= 0 {
+		return false
+	}
+
+	evicted := false
+	for c.bytes > c.maxBytes && (c.t1Len > 0 || c.t2Len > 0) {
+		replaced := c.replace(false)
+		if replaced == nil {
+			// replace chose an empty T2 while T1 was within p; T1 holds
+			// every resident entry, so take its LRU regardless of p.
+			p := c.p
+			c.p = 0
+			replaced = c.replace(false)
+			c.p = p
+		}
+		if replaced != nil {
+			*events = append(*events, cacheutil.Event[K, V]{Type: EventEvict, Key: replaced.key, Value: replaced.value, Reason: ReasonCapacity})
+		}
+		evicted = true
+	}
+	return evicted
+}
+
+// makeRoomForNewKey implements ARC's case IV (cache miss) bookkeeping for a
+// key that is not present in T1, T2, B1 or B2, appending an EventEvict for
+// any entry it removes or demotes to events.
+func (c *Cache[K, V]) makeRoomForNewKey(events *[]cacheutil.Event[K, V]) (evicted bool) {
+	if c.t1Len+c.b1Len == c.capacity {
+		if c.t1Len < c.capacity {
+			c.dropGhostLRU(&c.b1Head, &c.b1Tail, &c.b1Len)
+			if replaced := c.replace(false); replaced != nil {
+				*events = append(*events, cacheutil.Event[K, V]{Type: EventEvict, Key: replaced.key, Value: replaced.value, Reason: ReasonReplace})
+			}
+			return true
+		}
+
+		oldest := c.t1Tail
+		if oldest == nil {
+			return false
+		}
+		c.unlink(&c.t1Head, &c.t1Tail, oldest)
+		c.t1Len--
+		delete(c.data, oldest.key)
+		c.bytes -= oldest.size
+		*events = append(*events, cacheutil.Event[K, V]{Type: EventEvict, Key: oldest.key, Value: oldest.value, Reason: ReasonCapacity})
+		return true
+	}
+
+	total := c.t1Len + c.t2Len + c.b1Len + c.b2Len
+	if total >= c.capacity {
+		if total == 2*c.capacity {
+			c.dropGhostLRU(&c.b2Head, &c.b2Tail, &c.b2Len)
+		}
+		if replaced := c.replace(false); replaced != nil {
+			*events = append(*events, cacheutil.Event[K, V]{Type: EventEvict, Key: replaced.key, Value: replaced.value, Reason: ReasonReplace})
+		}
+		return true
+	}
+
+	return false
+}
+
+// replace evicts the LRU of T1 or T2 into the matching ghost list, per
+// ARC's REPLACE(x) procedure, and returns the evicted entry's key and value.
+// T2 is chosen whenever T1 is empty or within its target p, so replace
+// returns nil if T2 is empty at that point, even when T1 holds entries.
+// xInB2 indicates the miss that triggered this replace was a ghost hit in
+// B2, which biases the choice towards T2.
+func (c *Cache[K, V]) replace(xInB2 bool) *entry[K, V] {
+	if c.t1Len >= 1 && (c.t1Len > c.p || (xInB2 && c.t1Len == c.p)) {
+		node := c.t1Tail
+		if node == nil {
+			return nil
+		}
+		c.unlink(&c.t1Head, &c.t1Tail, node)
+		c.t1Len--
+		c.bytes -= node.size
+
+		evicted := &entry[K, V]{key: node.key, value: node.value}
+
+		var zero V
+		node.value = zero
+		node.size = 0
+		node.loc = locB1
+		c.pushFront(&c.b1Head, &c.b1Tail, node)
+		c.b1Len++
+		return evicted
+	}
+
+	node := c.t2Tail
+	if node == nil {
+		return nil
+	}
+	c.unlink(&c.t2Head, &c.t2Tail, node)
+	c.t2Len--
+	c.bytes -= node.size
+
+	evicted := &entry[K, V]{key: node.key, value: node.value}
+
+	var zero V
+	node.value = zero
+	node.size = 0
+	node.loc = locB2
+	c.pushFront(&c.b2Head, &c.b2Tail, node)
+	c.b2Len++
+	return evicted
+}
+
+func (c *Cache[K, V]) dropGhostLRU(head, tail **entry[K, V], length *uint) {
+	oldest := *tail
+	if oldest == nil {
+		return
+	}
+	c.unlink(head, tail, oldest)
+	*length--
+	delete(c.data, oldest.key)
+}
+
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	var events []cacheutil.Event[K, V]
+	var value V
+	var found bool
+
+	func() {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		node, has := c.data[key]
+		if !has || node.loc == locB1 || node.loc == locB2 {
+			return
+		}
+
+		if node.expired(time.Now()) {
+			switch node.loc {
+			case locT1:
+				c.unlink(&c.t1Head, &c.t1Tail, node)
+				c.t1Len--
+			case locT2:
+				c.unlink(&c.t2Head, &c.t2Tail, node)
+				c.t2Len--
+			}
+			delete(c.data, key)
+			c.bytes -= node.size
+			events = append(events, cacheutil.Event[K, V]{Type: EventEvict, Key: key, Value: node.value, Reason: ReasonTTL})
+			return
+		}
+
+		if node.loc == locT1 {
+			c.unlink(&c.t1Head, &c.t1Tail, node)
+			c.t1Len--
+			node.loc = locT2
+			c.pushFront(&c.t2Head, &c.t2Tail, node)
+			c.t2Len++
+		} else {
+			c.unlink(&c.t2Head, &c.t2Tail, node)
+			c.pushFront(&c.t2Head, &c.t2Tail, node)
+		}
+
+		value, found = node.value, true
+	}()
+
+	c.events.DispatchAll(events)
+	return value, found
+}
+
+// Close stops the background janitor goroutine, if one was started via
+// WithJanitor. It is safe to call more than once.
+func (c *Cache[K, V]) Close() error {
+	return c.janitor.Close()
+}
+
+func (c *Cache[K, V]) sweepExpired() {
+	now := time.Now()
+	var events []cacheutil.Event[K, V]
+
+	func() {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		for key, node := range c.data {
+			if node.loc != locT1 && node.loc != locT2 {
+				continue
+			}
+			if !node.expired(now) {
+				continue
+			}
+			switch node.loc {
+			case locT1:
+				c.unlink(&c.t1Head, &c.t1Tail, node)
+				c.t1Len--
+			case locT2:
+				c.unlink(&c.t2Head, &c.t2Tail, node)
+				c.t2Len--
+			}
+			delete(c.data, key)
+			c.bytes -= node.size
+			events = append(events, cacheutil.Event[K, V]{Type: EventEvict, Key: key, Value: node.value, Reason: ReasonTTL})
+		}
+	}()
+
+	c.events.DispatchAll(events)
+}
+
+func (c *Cache[K, V]) Remove(key K) bool {
+	var events []cacheutil.Event[K, V]
+	var removed bool
+
+	func() {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		node, has := c.data[key]
+		if !has {
+			return
+		}
+
+		switch node.loc {
+		case locT1:
+			c.unlink(&c.t1Head, &c.t1Tail, node)
+			c.t1Len--
+		case locT2:
+			c.unlink(&c.t2Head, &c.t2Tail, node)
+			c.t2Len--
+		case locB1:
+			c.unlink(&c.b1Head, &c.b1Tail, node)
+			c.b1Len--
+		case locB2:
+			c.unlink(&c.b2Head, &c.b2Tail, node)
+			c.b2Len--
+		}
+
+		delete(c.data, key)
+		c.bytes -= node.size
+		if node.loc == locT1 || node.loc == locT2 {
+			events = append(events, cacheutil.Event[K, V]{Type: EventRemove, Key: key, Value: node.value, Reason: ReasonManual})
+		}
+		removed = true
+	}()
+
+	c.events.DispatchAll(events)
+	return removed
+}
+
+// Len returns the number of resident entries currently cached (T1 and T2;
+// ghost entries in B1/B2 are not counted).
+func (c *Cache[K, V]) Len() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return int(c.t1Len + c.t2Len)
+}
+
+// Bytes returns the total size of resident entries as reported by
+// WithSizer. It is always 0 without WithSizer configured.
+func (c *Cache[K, V]) Bytes() int64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.bytes
+}
+
+func (c *Cache[K, V]) pushFront(head, tail **entry[K, V], node *entry[K, V]) {
+	node.prev = nil
+	node.next = *head
+	if *head != nil {
+		(*head).prev = node
+	} else {
+		*tail = node
+	}
+	*head = node
+}
+
+func (c *Cache[K, V]) unlink(head, tail **entry[K, V], node *entry[K, V]) {
+	if node.prev != nil {
+		node.prev.next = node.next
+	} else {
+		*head = node.next
+	}
+	if node.next != nil {
+		node.next.prev = node.prev
+	} else {
+		*tail = node.prev
+	}
+	node.prev = nil
+	node.next = nil
+}
+
+// Subscribe returns a buffered channel of insert/evict/remove events. Call
+// Unsubscribe to stop receiving events and release the channel.
+func (c *Cache[K, V]) Subscribe() <-chan cacheutil.Event[K, V] {
+	return c.events.Subscribe()
+}
+
+// Unsubscribe stops ch from receiving further events and closes it. It is
+// a no-op if ch was not returned by Subscribe or was already unsubscribed.
+func (c *Cache[K, V]) Unsubscribe(ch <-chan cacheutil.Event[K, V]) {
+	c.events.Unsubscribe(ch)
+}
@@ -0,0 +1,321 @@
+package arc
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewCache(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity uint
+		wantErr  bool
+	}{
+		{
+			name:     "valid capacity",
+			capacity: 4,
+			wantErr:  false,
+		},
+		{
+			name:     "zero capacity",
+			capacity: 0,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache, err := NewCache[string, int](tt.capacity)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewCache() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && cache == nil {
+				t.Error("NewCache() returned nil cache without error")
+			}
+		})
+	}
+}
+
+func TestCache_AddAndGet(t *testing.T) {
+	cache, _ := NewCache[string, int](2)
+
+	cache.Add("key1", 1)
+	cache.Add("key2", 2)
+
+	if value, found := cache.Get("key1"); !found || value != 1 {
+		t.Errorf("Get(key1) = %v, %v, want 1, true", value, found)
+	}
+	if _, found := cache.Get("key3"); found {
+		t.Error("Get(key3) should miss")
+	}
+}
+
+func TestCache_HitPromotesT1ToT2(t *testing.T) {
+	cache, _ := NewCache[string, int](2)
+
+	cache.Add("key1", 1)
+	cache.Get("key1") // T1 hit -> promoted to T2
+
+	if cache.t1Len != 0 || cache.t2Len != 1 {
+		t.Errorf("expected key1 in T2 after a hit, t1Len=%d t2Len=%d", cache.t1Len, cache.t2Len)
+	}
+}
+
+func TestCache_Remove(t *testing.T) {
+	cache, _ := NewCache[string, int](2)
+	cache.Add("key1", 1)
+
+	if !cache.Remove("key1") {
+		t.Error("Remove() on an existing key should return true")
+	}
+	if cache.Remove("key1") {
+		t.Error("Remove() on a missing key should return false")
+	}
+}
+
+// TestCache_GhostHitAdaptsP exercises ARC's core self-tuning behaviour: a
+// ghost hit in B1 should grow p (favouring recency) and evict T2's LRU into
+// B2, and the promoted key should land in T2.
+func TestCache_GhostHitAdaptsP(t *testing.T) {
+	cache, _ := NewCache[string, int](2)
+
+	cache.Add("a", 1)
+	cache.Add("a", 11) // promote a into T2
+	cache.Add("b", 2)  // T1: [b]
+	cache.Add("c", 3)  // cache full: replace() evicts b (T1 LRU) into B1, then inserts c into T1
+
+	if cache.b1Len != 1 {
+		t.Fatalf("expected a ghost entry in B1, b1Len = %d", cache.b1Len)
+	}
+	pBefore := cache.p
+
+	evicted, rewritten := cache.Add("b", 22) // ghost hit in B1
+	if rewritten {
+		t.Error("a ghost-hit insert should not report rewritten")
+	}
+	if !evicted {
+		t.Error("a ghost-hit insert should report an eviction via replace()")
+	}
+	if cache.p <= pBefore {
+		t.Errorf("p should have grown after a B1 ghost hit, before=%d after=%d", pBefore, cache.p)
+	}
+	if cache.b2Len != 1 {
+		t.Errorf("replace() should have evicted T2's LRU into B2, b2Len = %d", cache.b2Len)
+	}
+
+	node, has := cache.data["b"]
+	if !has || node.loc != locT2 {
+		t.Error("the promoted B1 ghost hit should land in T2")
+	}
+}
+
+// TestCache_B2GhostHitShrinksP mirrors the B1 case: a B2 ghost hit should
+// shrink p (favouring frequency).
+func TestCache_B2GhostHitShrinksP(t *testing.T) {
+	cache, _ := NewCache[string, int](2)
+
+	cache.Add("a", 1)
+	cache.Add("a", 11) // promote a into T2
+	cache.Add("b", 2)
+	cache.Add("c", 3)  // evicts b into B1, p still 0
+	cache.Add("b", 22) // B1 ghost hit: p grows to 1, evicts a (T2 LRU) into B2
+
+	if cache.b2Len != 1 {
+		t.Fatalf("expected a ghost entry in B2, b2Len = %d", cache.b2Len)
+	}
+	pBefore := cache.p
+
+	evicted, rewritten := cache.Add("a", 111) // ghost hit in B2
+	if rewritten {
+		t.Error("a ghost-hit insert should not report rewritten")
+	}
+	if !evicted {
+		t.Error("a ghost-hit insert should report an eviction via replace()")
+	}
+	if cache.p >= pBefore {
+		t.Errorf("p should have shrunk after a B2 ghost hit, before=%d after=%d", pBefore, cache.p)
+	}
+
+	node, has := cache.data["a"]
+	if !has || node.loc != locT2 {
+		t.Error("the promoted B2 ghost hit should land in T2")
+	}
+}
+
+func TestCache_Concurrent(t *testing.T) {
+	cache, _ := NewCache[int, int](100)
+	done := make(chan bool)
+
+	go func() {
+		for i := 0; i < 200; i++ {
+			cache.Add(i%50, i)
+		}
+		done <- true
+	}()
+
+	go func() {
+		for i := 0; i < 200; i++ {
+			cache.Get(i % 50)
+		}
+		done <- true
+	}()
+
+	go func() {
+		for i := 0; i < 100; i++ {
+			cache.Remove(i % 50)
+		}
+		done <- true
+	}()
+
+	for i := 0; i < 3; i++ {
+		<-done
+	}
+}
+
+func TestCache_AddWithTTL_Expires(t *testing.T) {
+	cache, _ := NewCache[string, int](2)
+
+	cache.AddWithTTL("key1", 1, 10*time.Millisecond)
+	if _, found := cache.Get("key1"); !found {
+		t.Fatal("key1 should be present before it expires")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if _, found := cache.Get("key1"); found {
+		t.Error("key1 should have expired")
+	}
+}
+
+func TestCache_WithDefaultTTL(t *testing.T) {
+	cache, _ := NewCacheWithOptions[string, int](2, WithDefaultTTL[string, int](10*time.Millisecond))
+
+	cache.Add("key1", 1)
+	time.Sleep(20 * time.Millisecond)
+
+	if _, found := cache.Get("key1"); found {
+		t.Error("key1 should have expired under the default TTL")
+	}
+}
+
+func TestCache_WithJanitor_SweepsExpiredEntries(t *testing.T) {
+	cache, _ := NewCacheWithOptions[string, int](2, WithJanitor[string, int](5*time.Millisecond))
+	defer cache.Close()
+
+	cache.AddWithTTL("key1", 1, 5*time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+
+	cache.lock.RLock()
+	_, has := cache.data["key1"]
+	cache.lock.RUnlock()
+
+	if has {
+		t.Error("janitor should have swept the expired entry out of the map")
+	}
+}
+
+func TestCache_Close_StopsJanitor(t *testing.T) {
+	cache, _ := NewCacheWithOptions[string, int](2, WithJanitor[string, int](5*time.Millisecond))
+
+	if err := cache.Close(); err != nil {
+		t.Errorf("Close() returned an error: %v", err)
+	}
+	if err := cache.Close(); err != nil {
+		t.Errorf("second Close() returned an error: %v", err)
+	}
+}
+
+func TestCache_SizeBasedEviction(t *testing.T) {
+	sizer := func(v string) int64 { return int64(len(v)) }
+	cache, _ := NewCacheWithOptions[string, string](10, WithSizer[string, string](sizer), WithMaxBytes[string, string](5))
+
+	cache.Add("key1", "abc") // 3 bytes
+	cache.Add("key2", "de")  // 2 bytes, total 5, within budget
+
+	if cache.Bytes() != 5 {
+		t.Fatalf("Bytes() = %d, want 5", cache.Bytes())
+	}
+	if cache.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", cache.Len())
+	}
+
+	evicted, _ := cache.Add("key3", "fg") // pushes total to 7, over budget: evicts key1 (T1 LRU) into B1
+	if !evicted {
+		t.Error("adding past the byte budget should report an eviction")
+	}
+	if _, found := cache.Get("key1"); found {
+		t.Error("key1 should have been evicted to stay under the byte budget")
+	}
+	if cache.Bytes() > 5 {
+		t.Errorf("Bytes() = %d, should not exceed the 5 byte budget", cache.Bytes())
+	}
+}
+
+func TestCache_AddWithTTL_RejectsOversizedEntry(t *testing.T) {
+	sizer := func(v string) int64 { return int64(len(v)) }
+	cache, _ := NewCacheWithOptions[string, string](10, WithSizer[string, string](sizer), WithMaxBytes[string, string](5))
+
+	_, _, err := cache.AddWithTTL("key1", "way too big", 0)
+	if err != ErrSizeExceedCapacity {
+		t.Errorf("AddWithTTL() error = %v, want ErrSizeExceedCapacity", err)
+	}
+	if _, found := cache.Get("key1"); found {
+		t.Error("an oversized entry should not have been added")
+	}
+}
+
+func TestCache_WithOnEvict_ReplaceReason(t *testing.T) {
+	type evictedEntry struct {
+		key    string
+		value  int
+		reason EvictReason
+	}
+	var mu sync.Mutex
+	var evictions []evictedEntry
+
+	cache, _ := NewCacheWithOptions[string, int](2, WithOnEvict[string, int](func(key string, value int, reason EvictReason) {
+		mu.Lock()
+		defer mu.Unlock()
+		evictions = append(evictions, evictedEntry{key, value, reason})
+	}))
+
+	cache.Add("a", 1)
+	cache.Add("a", 11) // promote a into T2
+	cache.Add("b", 2)  // T1: [b]
+	cache.Add("c", 3)  // cache full: replace() evicts b (T1 LRU) into B1
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(evictions) != 1 || evictions[0].key != "b" || evictions[0].value != 2 || evictions[0].reason != ReasonReplace {
+		t.Errorf("evictions = %+v, want one replace eviction of b=2", evictions)
+	}
+}
+
+func TestCache_Subscribe(t *testing.T) {
+	cache, _ := NewCache[string, int](1)
+	ch := cache.Subscribe()
+	defer cache.Unsubscribe(ch)
+
+	cache.Add("key1", 1)
+	if ev := <-ch; ev.Type != EventInsert || ev.Key != "key1" || ev.Value != 1 {
+		t.Errorf("first event = %+v, want insert key1=1", ev)
+	}
+
+	cache.Remove("key1")
+	if ev := <-ch; ev.Type != EventRemove || ev.Key != "key1" {
+		t.Errorf("second event = %+v, want removal of key1", ev)
+	}
+}
+
+func TestCache_Unsubscribe(t *testing.T) {
+	cache, _ := NewCache[string, int](2)
+	sub := cache.Subscribe()
+	cache.Unsubscribe(sub)
+
+	cache.Add("key1", 1)
+	if _, open := <-sub; open {
+		t.Error("an unsubscribed channel should be closed, not receive further events")
+	}
+}
@@ -0,0 +1,160 @@
+// Package cacheutil holds the eviction-event and background-janitor
+// machinery shared by every cache policy in this module (lru, lfu, arc,
+// twoq, sieve), so each policy only needs to define its own eviction
+// algorithm and wire it into a Bus and a Janitor.
+package cacheutil
+
+import (
+	"sync"
+	"time"
+)
+
+// EvictReason identifies why an entry left a cache, for an EventEvict Event
+// or an eviction callback.
+type EvictReason uint8
+
+const (
+	ReasonCapacity EvictReason = iota
+	ReasonTTL
+	ReasonManual
+	// ReasonReplace marks an eviction made by ARC's own REPLACE(x) procedure,
+	// which demotes a T1 or T2 entry into its ghost list to admit a new or
+	// promoted one. Unused by the other policies.
+	ReasonReplace
+)
+
+// EventType identifies the kind of change an Event reports.
+type EventType uint8
+
+const (
+	EventInsert EventType = iota
+	EventEvict
+	EventRemove
+)
+
+// Event describes a single insert, eviction, or removal published to
+// subscribers registered via Bus.Subscribe. Reason is only meaningful for
+// EventEvict.
+type Event[K comparable, V any] struct {
+	Type   EventType
+	Key    K
+	Value  V
+	Reason EvictReason
+}
+
+// SubscriberBuffer is the channel buffer size used by Bus.Subscribe.
+const SubscriberBuffer = 16
+
+// Bus fans eviction events out to an optional callback and any number of
+// subscriber channels. The zero value is ready to use.
+type Bus[K comparable, V any] struct {
+	onEvict func(key K, value V, reason EvictReason)
+
+	lock        sync.Mutex
+	subscribers map[chan Event[K, V]]struct{}
+}
+
+// SetOnEvict registers the callback invoked for EventEvict events. Callers
+// set it once, during construction, before the cache is used concurrently.
+func (b *Bus[K, V]) SetOnEvict(f func(key K, value V, reason EvictReason)) {
+	b.onEvict = f
+}
+
+// Subscribe returns a buffered channel of insert/evict/remove events. Call
+// Unsubscribe to stop receiving events and release the channel.
+func (b *Bus[K, V]) Subscribe() <-chan Event[K, V] {
+	ch := make(chan Event[K, V], SubscriberBuffer)
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.subscribers == nil {
+		b.subscribers = make(map[chan Event[K, V]]struct{})
+	}
+	b.subscribers[ch] = struct{}{}
+
+	return ch
+}
+
+// Unsubscribe stops ch from receiving further events and closes it. It is a
+// no-op if ch was not returned by Subscribe or was already unsubscribed.
+func (b *Bus[K, V]) Unsubscribe(ch <-chan Event[K, V]) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for sub := range b.subscribers {
+		if (<-chan Event[K, V])(sub) == ch {
+			delete(b.subscribers, sub)
+			close(sub)
+			return
+		}
+	}
+}
+
+// DispatchAll delivers each event in order, via Dispatch.
+func (b *Bus[K, V]) DispatchAll(events []Event[K, V]) {
+	for _, ev := range events {
+		b.Dispatch(ev)
+	}
+}
+
+// Dispatch invokes the onEvict callback (for EventEvict) and forwards ev to
+// all subscribers. Sends are non-blocking: a subscriber that isn't keeping
+// up misses events rather than stalling cache operations. The caller must
+// not hold the cache's own lock.
+func (b *Bus[K, V]) Dispatch(ev Event[K, V]) {
+	if ev.Type == EventEvict && b.onEvict != nil {
+		b.onEvict(ev.Key, ev.Value, ev.Reason)
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	for sub := range b.subscribers {
+		select {
+		case sub <- ev:
+		default:
+		}
+	}
+}
+
+// Janitor runs a sweep function on a fixed interval until Close. The zero
+// value is inert; call Start to begin sweeping.
+type Janitor struct {
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// Start launches the janitor goroutine, calling sweep every interval until
+// Close is called. Start must not be called more than once per Janitor.
+func (j *Janitor) Start(interval time.Duration, sweep func()) {
+	j.stop = make(chan struct{})
+	j.done = make(chan struct{})
+
+	go func() {
+		defer close(j.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				sweep()
+			case <-j.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops the janitor goroutine, if Start was called. It is safe to
+// call more than once, and safe to call without ever calling Start.
+func (j *Janitor) Close() error {
+	j.once.Do(func() {
+		if j.stop != nil {
+			close(j.stop)
+			<-j.done
+		}
+	})
+	return nil
+}
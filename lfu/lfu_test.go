@@ -1,7 +1,9 @@
 package lfu
 
 import (
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestNewLFUCache(t *testing.T) {
@@ -264,3 +266,149 @@ func TestLFUCache_FrequencyIncrement(t *testing.T) {
 		}
 	}
 }
+
+func TestLFUCache_AddWithTTL_Expires(t *testing.T) {
+	cache, _ := NewCache[string, int](2)
+
+	cache.AddWithTTL("key1", 1, 10*time.Millisecond)
+	if _, found := cache.Get("key1"); !found {
+		t.Fatal("key1 should be present before it expires")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if _, found := cache.Get("key1"); found {
+		t.Error("key1 should have expired")
+	}
+}
+
+func TestLFUCache_WithDefaultTTL(t *testing.T) {
+	cache, _ := NewCacheWithOptions[string, int](2, WithDefaultTTL[string, int](10*time.Millisecond))
+
+	cache.Add("key1", 1)
+	time.Sleep(20 * time.Millisecond)
+
+	if _, found := cache.Get("key1"); found {
+		t.Error("key1 should have expired under the default TTL")
+	}
+}
+
+func TestLFUCache_WithJanitor_SweepsExpiredEntries(t *testing.T) {
+	cache, _ := NewCacheWithOptions[string, int](2, WithJanitor[string, int](5*time.Millisecond))
+	defer cache.Close()
+
+	cache.AddWithTTL("key1", 1, 5*time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+
+	cache.lock.RLock()
+	_, has := cache.data["key1"]
+	cache.lock.RUnlock()
+
+	if has {
+		t.Error("janitor should have swept the expired entry out of the map")
+	}
+}
+
+func TestLFUCache_Close_StopsJanitor(t *testing.T) {
+	cache, _ := NewCacheWithOptions[string, int](2, WithJanitor[string, int](5*time.Millisecond))
+
+	if err := cache.Close(); err != nil {
+		t.Errorf("Close() returned an error: %v", err)
+	}
+	// Closing twice must not panic or block.
+	if err := cache.Close(); err != nil {
+		t.Errorf("second Close() returned an error: %v", err)
+	}
+}
+
+func TestLFUCache_SizeBasedEviction(t *testing.T) {
+	sizer := func(v string) int64 { return int64(len(v)) }
+	cache, _ := NewCacheWithOptions[string, string](10, WithSizer[string, string](sizer), WithMaxBytes[string, string](5))
+
+	cache.Add("key1", "abc") // 3 bytes
+	cache.Add("key2", "de")  // 2 bytes, total 5, within budget
+
+	if cache.Bytes() != 5 {
+		t.Fatalf("Bytes() = %d, want 5", cache.Bytes())
+	}
+	if cache.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", cache.Len())
+	}
+
+	evicted, _ := cache.Add("key3", "fg") // pushes total to 7, over budget: evicts key1 (least frequent)
+	if !evicted {
+		t.Error("adding past the byte budget should report an eviction")
+	}
+	if _, found := cache.Get("key1"); found {
+		t.Error("key1 should have been evicted to stay under the byte budget")
+	}
+	if cache.Bytes() > 5 {
+		t.Errorf("Bytes() = %d, should not exceed the 5 byte budget", cache.Bytes())
+	}
+}
+
+func TestLFUCache_AddWithTTL_RejectsOversizedEntry(t *testing.T) {
+	sizer := func(v string) int64 { return int64(len(v)) }
+	cache, _ := NewCacheWithOptions[string, string](10, WithSizer[string, string](sizer), WithMaxBytes[string, string](5))
+
+	_, _, err := cache.AddWithTTL("key1", "way too big", 0)
+	if err != ErrSizeExceedCapacity {
+		t.Errorf("AddWithTTL() error = %v, want ErrSizeExceedCapacity", err)
+	}
+	if _, found := cache.Get("key1"); found {
+		t.Error("an oversized entry should not have been added")
+	}
+}
+
+func TestLFUCache_WithOnEvict(t *testing.T) {
+	type evictedEntry struct {
+		key    string
+		value  int
+		reason EvictReason
+	}
+	var mu sync.Mutex
+	var evictions []evictedEntry
+
+	cache, _ := NewCacheWithOptions[string, int](2, WithOnEvict[string, int](func(key string, value int, reason EvictReason) {
+		mu.Lock()
+		defer mu.Unlock()
+		evictions = append(evictions, evictedEntry{key, value, reason})
+	}))
+
+	cache.Add("key1", 1)
+	cache.Add("key2", 2)
+	cache.Add("key3", 3) // evicts key1 (tied least frequent, oldest)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(evictions) != 1 || evictions[0].key != "key1" || evictions[0].value != 1 || evictions[0].reason != ReasonCapacity {
+		t.Errorf("evictions = %+v, want one capacity eviction of key1=1", evictions)
+	}
+}
+
+func TestLFUCache_Subscribe(t *testing.T) {
+	cache, _ := NewCache[string, int](2)
+	ch := cache.Subscribe()
+	defer cache.Unsubscribe(ch)
+
+	cache.Add("key1", 1)
+	if ev := <-ch; ev.Type != EventInsert || ev.Key != "key1" || ev.Value != 1 {
+		t.Errorf("first event = %+v, want insert key1=1", ev)
+	}
+
+	cache.Remove("key1")
+	if ev := <-ch; ev.Type != EventRemove || ev.Key != "key1" {
+		t.Errorf("second event = %+v, want removal of key1", ev)
+	}
+}
+
+func TestLFUCache_Unsubscribe(t *testing.T) {
+	cache, _ := NewCache[string, int](2)
+	sub := cache.Subscribe()
+	cache.Unsubscribe(sub)
+
+	cache.Add("key1", 1)
+	if _, open := <-sub; open {
+		t.Error("an unsubscribed channel should be closed, not receive further events")
+	}
+}
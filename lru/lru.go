@@ -3,84 +3,306 @@ package lru
 import (
 	"errors"
 	"sync"
+	"time"
+
+	"github.com/kolobok-kelbek/cacherno/internal/cacheutil"
+)
+
+// ErrSizeExceedCapacity is returned by AddWithTTL when a value's size, as
+// reported by a WithSizer func, exceeds the cache's WithMaxBytes budget on
+// its own.
+var ErrSizeExceedCapacity = errors.New("value size exceeds max bytes capacity")
+
+// EvictReason identifies why an entry left the cache, for an EventEvict
+// Event or a WithOnEvict callback.
+type EvictReason = cacheutil.EvictReason
+
+const (
+	ReasonCapacity = cacheutil.ReasonCapacity
+	ReasonTTL      = cacheutil.ReasonTTL
+	ReasonManual   = cacheutil.ReasonManual
+)
+
+// EventType identifies the kind of change an Event reports.
+type EventType = cacheutil.EventType
+
+const (
+	EventInsert = cacheutil.EventInsert
+	EventEvict  = cacheutil.EventEvict
+	EventRemove = cacheutil.EventRemove
 )
 
 type entry[K comparable, V any] struct {
 	key   K
 	value V
+	size  int64
+
+	expiresAt time.Time
 
 	prev *entry[K, V]
 	next *entry[K, V]
 }
 
+func (e *entry[K, V]) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
+}
+
 type Cache[K comparable, V any] struct {
 	data     map[K]*entry[K, V]
 	lock     sync.RWMutex
 	capacity uint
 	head     *entry[K, V]
 	tail     *entry[K, V]
+
+	defaultTTL time.Duration
+
+	janitorInterval time.Duration
+	janitor         cacheutil.Janitor
+
+	sizer    func(V) int64
+	maxBytes int64
+	bytes    int64
+
+	events cacheutil.Bus[K, V]
+}
+
+// Option configures optional behavior on a Cache constructed via
+// NewCacheWithOptions.
+type Option[K comparable, V any] func(*Cache[K, V])
+
+// WithDefaultTTL sets the expiration applied to entries added via Add (and
+// via AddWithTTL when passed a non-positive ttl). Entries never expire by
+// default.
+func WithDefaultTTL[K comparable, V any](d time.Duration) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.defaultTTL = d
+	}
+}
+
+// WithJanitor starts a background goroutine that sweeps expired entries
+// every interval. Call Close to stop it.
+func WithJanitor[K comparable, V any](interval time.Duration) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.janitorInterval = interval
+	}
+}
+
+// WithSizer enables byte-size accounting: f reports the size of a value,
+// and that size is added to Bytes() and tracked per entry. Pair with
+// WithMaxBytes to cap the cache by total size in addition to entry count.
+func WithSizer[K comparable, V any](f func(V) int64) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.sizer = f
+	}
+}
+
+// WithMaxBytes caps the cache's total tracked size, evicting entries (in
+// the cache's normal eviction order) after each Add until the budget is
+// met. Has no effect without WithSizer.
+func WithMaxBytes[K comparable, V any](n int64) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.maxBytes = n
+	}
+}
+
+// WithOnEvict registers a callback invoked whenever an entry is evicted.
+// It runs after the internal lock has been released, so it may safely call
+// back into the cache.
+func WithOnEvict[K comparable, V any](f func(key K, value V, reason EvictReason)) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.events.SetOnEvict(f)
+	}
 }
 
 func NewCache[K comparable, V any](capacity uint) (*Cache[K, V], error) {
+	return NewCacheWithOptions[K, V](capacity)
+}
+
+func NewCacheWithOptions[K comparable, V any](capacity uint, opts ...Option[K, V]) (*Cache[K, V], error) {
 	if capacity <= 0 {
 		return nil, errors.New("must provide a positive size")
 	}
 
-	return &Cache[K, V]{
+	c := &Cache[K, V]{
 		data:     make(map[K]*entry[K, V], capacity),
-		lock:     sync.RWMutex{},
 		capacity: capacity,
-	}, nil
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.janitorInterval > 0 {
+		c.janitor.Start(c.janitorInterval, c.sweepExpired)
+	}
+
+	return c, nil
 }
 
 func (c *Cache[K, V]) Add(key K, value V) (evicted bool, rewritten bool) {
-	c.lock.Lock()
-	defer c.lock.Unlock()
+	evicted, rewritten, _ = c.addWithTTL(key, value, c.defaultTTL)
+	return
+}
 
-	if node, has := c.data[key]; has {
-		node.value = value
-		c.moveToFront(node)
-		return false, true
-	}
+// AddWithTTL adds key with an expiration ttl after which it is treated as a
+// miss and transparently removed. A non-positive ttl falls back to the
+// cache's default TTL, if any. It returns ErrSizeExceedCapacity, without
+// adding the entry, when a WithSizer/WithMaxBytes budget is configured and
+// value alone exceeds it.
+func (c *Cache[K, V]) AddWithTTL(key K, value V, ttl time.Duration) (evicted bool, rewritten bool, err error) {
+	return c.addWithTTL(key, value, ttl)
+}
 
-	node := &entry[K, V]{
-		key:   key,
-		value: value,
-	}
-	c.data[key] = node
-	c.addToFront(node)
+func (c *Cache[K, V]) addWithTTL(key K, value V, ttl time.Duration) (evicted bool, rewritten bool, err error) {
+	var events []cacheutil.Event[K, V]
+	func() {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		var size int64
+		if c.sizer != nil {
+			size = c.sizer(value)
+			if c.maxBytes > 0 && size > c.maxBytes {
+				err = ErrSizeExceedCapacity
+				return
+			}
+		}
+
+		if ttl <= 0 {
+			ttl = c.defaultTTL
+		}
+		var expiresAt time.Time
+		if ttl > 0 {
+			expiresAt = time.Now().Add(ttl)
+		}
+
+		if node, has := c.data[key]; has {
+			c.bytes += size - node.size
+			node.value = value
+			node.size = size
+			node.expiresAt = expiresAt
+			c.moveToFront(node)
+			rewritten = true
+			events = append(events, cacheutil.Event[K, V]{Type: EventInsert, Key: key, Value: value})
+			if c.evictOverBudget(&events) {
+				evicted = true
+			}
+			return
+		}
+
+		node := &entry[K, V]{
+			key:       key,
+			value:     value,
+			size:      size,
+			expiresAt: expiresAt,
+		}
+		c.data[key] = node
+		c.addToFront(node)
+		c.bytes += size
+		events = append(events, cacheutil.Event[K, V]{Type: EventInsert, Key: key, Value: value})
+
+		if uint(len(c.data)) > c.capacity {
+			if tail := c.removeTail(); tail != nil {
+				events = append(events, cacheutil.Event[K, V]{Type: EventEvict, Key: tail.key, Value: tail.value, Reason: ReasonCapacity})
+			}
+			evicted = true
+		}
+		if c.evictOverBudget(&events) {
+			evicted = true
+		}
+	}()
 
-	if uint(len(c.data)) > c.capacity {
-		c.removeTail()
-		return true, false
+	c.events.DispatchAll(events)
+	return evicted, rewritten, err
+}
+
+// evictOverBudget evicts LRU entries while the cache is over its
+// WithMaxBytes budget, appending an EventEvict for each to events and
+// returning whether it evicted anything.
+func (c *Cache[K, V]) evictOverBudget(events *[]cacheutil.Event[K, V]) bool {
+	if c.maxBytes <= 0 {
+		return false
 	}
 
-	return false, false
+	evicted := false
+	for c.bytes > c.maxBytes && c.tail != nil {
+		node := c.removeTail()
+		*events = append(*events, cacheutil.Event[K, V]{Type: EventEvict, Key: node.key, Value: node.value, Reason: ReasonCapacity})
+		evicted = true
+	}
+	return evicted
 }
 
 func (c *Cache[K, V]) Get(key K) (V, bool) {
-	c.lock.RLock()
-	defer c.lock.RUnlock()
+	var events []cacheutil.Event[K, V]
+	value, found := func() (value V, found bool) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		node, has := c.data[key]
+		if !has {
+			return value, false
+		}
+
+		if node.expired(time.Now()) {
+			c.removeNode(node)
+			delete(c.data, key)
+			events = append(events, cacheutil.Event[K, V]{Type: EventEvict, Key: key, Value: node.value, Reason: ReasonTTL})
+			return value, false
+		}
 
-	if node, has := c.data[key]; has {
 		c.moveToFront(node)
 		return node.value, true
-	}
+	}()
 
-	var zero V
-	return zero, false
+	c.events.DispatchAll(events)
+	return value, found
 }
 
 func (c *Cache[K, V]) Remove(key K) bool {
-	c.lock.Lock()
-	defer c.lock.Unlock()
+	var events []cacheutil.Event[K, V]
+	removed := func() bool {
+		c.lock.Lock()
+		defer c.lock.Unlock()
 
-	if node, has := c.data[key]; has {
+		node, has := c.data[key]
+		if !has {
+			return false
+		}
 		c.removeNode(node)
 		delete(c.data, key)
+		events = append(events, cacheutil.Event[K, V]{Type: EventRemove, Key: key, Value: node.value, Reason: ReasonManual})
 		return true
-	}
-	return false
+	}()
+
+	c.events.DispatchAll(events)
+	return removed
+}
+
+// Close stops the background janitor goroutine, if one was started via
+// WithJanitor. It is safe to call more than once.
+func (c *Cache[K, V]) Close() error {
+	return c.janitor.Close()
+}
+
+func (c *Cache[K, V]) sweepExpired() {
+	now := time.Now()
+
+	var events []cacheutil.Event[K, V]
+	func() {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		for key, node := range c.data {
+			if node.expired(now) {
+				c.removeNode(node)
+				delete(c.data, key)
+				events = append(events, cacheutil.Event[K, V]{Type: EventEvict, Key: key, Value: node.value, Reason: ReasonTTL})
+			}
+		}
+	}()
+
+	c.events.DispatchAll(events)
 }
 
 func (c *Cache[K, V]) moveToFront(node *entry[K, V]) {
@@ -116,13 +338,47 @@ func (c *Cache[K, V]) removeFromList(node *entry[K, V]) {
 	}
 }
 
-func (c *Cache[K, V]) removeTail() {
-	if c.tail != nil {
-		delete(c.data, c.tail.key)
-		c.removeFromList(c.tail)
+// removeTail evicts the LRU entry, returning it, or nil if the cache is
+// empty.
+func (c *Cache[K, V]) removeTail() *entry[K, V] {
+	node := c.tail
+	if node == nil {
+		return nil
 	}
+	c.bytes -= node.size
+	delete(c.data, node.key)
+	c.removeFromList(node)
+	return node
 }
 
 func (c *Cache[K, V]) removeNode(node *entry[K, V]) {
+	c.bytes -= node.size
 	c.removeFromList(node)
 }
+
+// Len returns the number of entries currently cached.
+func (c *Cache[K, V]) Len() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return len(c.data)
+}
+
+// Bytes returns the total size of cached entries as reported by WithSizer.
+// It is always 0 without WithSizer configured.
+func (c *Cache[K, V]) Bytes() int64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.bytes
+}
+
+// Subscribe returns a buffered channel of insert/evict/remove events. Call
+// Unsubscribe to stop receiving events and release the channel.
+func (c *Cache[K, V]) Subscribe() <-chan cacheutil.Event[K, V] {
+	return c.events.Subscribe()
+}
+
+// Unsubscribe stops ch from receiving further events and closes it. It is
+// a no-op if ch was not returned by Subscribe or was already unsubscribed.
+func (c *Cache[K, V]) Unsubscribe(ch <-chan cacheutil.Event[K, V]) {
+	c.events.Unsubscribe(ch)
+}
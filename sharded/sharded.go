@@ -0,0 +1,169 @@
+// Package sharded wraps a cacherno.Cache policy into N independent shards
+// so that concurrent callers touching different keys don't contend on a
+// single lock. Every operation hashes its key to pick a shard and then
+// delegates to that shard's own cache, which can be any policy (LRU, LFU,
+// ARC, 2Q, SIEVE) implementing cacherno.Cache.
+package sharded
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"hash/maphash"
+	"reflect"
+	"runtime"
+
+	"github.com/kolobok-kelbek/cacherno"
+)
+
+// Hasher maps a key to a shard-selection hash. Only the low bits of the
+// result are used, so a hash that doesn't spread those bits evenly will
+// concentrate keys onto a few shards.
+type Hasher[K comparable] func(key K) uint64
+
+// Option configures optional behavior on a Cache constructed via
+// NewShardedWithOptions.
+type Option[K comparable, V any] func(*Cache[K, V])
+
+// WithHasher overrides the default key hasher (maphash for strings,
+// fnv-1a over the integer's bits for integer kinds, and a reflection-based
+// fallback otherwise). Use this when the default distributes your keys
+// poorly across shards.
+func WithHasher[K comparable, V any](h Hasher[K]) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.hasher = h
+	}
+}
+
+// Cache wraps N independent cacherno.Cache shards behind a hash of the
+// key. It implements cacherno.Cache itself, so it's a drop-in replacement
+// for any single policy.
+type Cache[K comparable, V any] struct {
+	shards []cacherno.Cache[K, V]
+	mask   uint64
+	hasher Hasher[K]
+}
+
+// NewSharded builds a Cache of shards inner caches, each constructed via
+// factory with capacityPerShard. shards is rounded up to the next power of
+// two; a non-positive shards defaults to runtime.GOMAXPROCS(0)*4.
+func NewSharded[K comparable, V any](capacityPerShard uint, factory func(uint) (cacherno.Cache[K, V], error), shards int) (*Cache[K, V], error) {
+	return NewShardedWithOptions[K, V](capacityPerShard, factory, shards)
+}
+
+// NewShardedWithOptions is NewSharded with additional options, such as
+// WithHasher.
+func NewShardedWithOptions[K comparable, V any](capacityPerShard uint, factory func(uint) (cacherno.Cache[K, V], error), shards int, opts ...Option[K, V]) (*Cache[K, V], error) {
+	if shards <= 0 {
+		shards = defaultShardCount()
+	}
+	shards = nextPowerOfTwo(shards)
+
+	c := &Cache[K, V]{
+		shards: make([]cacherno.Cache[K, V], shards),
+		mask:   uint64(shards - 1),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.hasher == nil {
+		c.hasher = defaultHasher[K]()
+	}
+
+	for i := range c.shards {
+		shard, err := factory(capacityPerShard)
+		if err != nil {
+			return nil, err
+		}
+		c.shards[i] = shard
+	}
+
+	return c, nil
+}
+
+func (c *Cache[K, V]) shardFor(key K) cacherno.Cache[K, V] {
+	return c.shards[c.hasher(key)&c.mask]
+}
+
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	return c.shardFor(key).Get(key)
+}
+
+func (c *Cache[K, V]) Add(key K, value V) (evicted bool, rewritten bool) {
+	return c.shardFor(key).Add(key, value)
+}
+
+func (c *Cache[K, V]) Remove(key K) bool {
+	return c.shardFor(key).Remove(key)
+}
+
+// Len returns the aggregate entry count across all shards. Shards whose
+// policy doesn't expose a Len() int method (beyond the minimal
+// cacherno.Cache interface) contribute zero.
+func (c *Cache[K, V]) Len() int {
+	total := 0
+	for _, shard := range c.shards {
+		if sized, ok := shard.(interface{ Len() int }); ok {
+			total += sized.Len()
+		}
+	}
+	return total
+}
+
+var hashSeed = maphash.MakeSeed()
+
+// defaultHasher picks a hash function from K's underlying kind: maphash
+// for strings, fnv-1a over the integer's bits for integer kinds, and a
+// reflection-based fallback (hashing the %v formatting) for everything
+// else.
+func defaultHasher[K comparable]() Hasher[K] {
+	var zero K
+	if _, ok := any(zero).(string); ok {
+		return func(key K) uint64 {
+			return maphash.String(hashSeed, any(key).(string))
+		}
+	}
+
+	switch reflect.TypeOf(zero).Kind() {
+	case reflect.String:
+		return func(key K) uint64 {
+			return maphash.String(hashSeed, reflect.ValueOf(key).String())
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(key K) uint64 {
+			h := fnv.New64a()
+			_ = binary.Write(h, binary.LittleEndian, reflect.ValueOf(key).Int())
+			return h.Sum64()
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(key K) uint64 {
+			h := fnv.New64a()
+			_ = binary.Write(h, binary.LittleEndian, reflect.ValueOf(key).Uint())
+			return h.Sum64()
+		}
+	}
+
+	return func(key K) uint64 {
+		h := fnv.New64a()
+		fmt.Fprintf(h, "%v", key)
+		return h.Sum64()
+	}
+}
+
+// defaultShardCount is the shard count used when NewSharded is given a
+// non-positive shards argument.
+func defaultShardCount() int {
+	return runtime.GOMAXPROCS(0) * 4
+}
+
+func nextPowerOfTwo(n int) int {
+	if n <= 1 {
+		return 1
+	}
+	p := 1
+	for p < n {
+		p <<= 1
+	}
+	return p
+}
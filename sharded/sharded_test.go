@@ -0,0 +1,158 @@
+package sharded
+
+import (
+	"testing"
+
+	"github.com/kolobok-kelbek/cacherno"
+	"github.com/kolobok-kelbek/cacherno/lru"
+)
+
+func lruFactory(capacity uint) (cacherno.Cache[int, int], error) {
+	return lru.NewCache[int, int](capacity)
+}
+
+func TestNewSharded_RoundsShardsUpToPowerOfTwo(t *testing.T) {
+	tests := []struct {
+		name       string
+		shards     int
+		wantShards int
+	}{
+		{name: "already a power of two", shards: 8, wantShards: 8},
+		{name: "rounds up", shards: 5, wantShards: 8},
+		{name: "zero defaults to GOMAXPROCS*4", shards: 0, wantShards: nextPowerOfTwo(defaultShardCount())},
+		{name: "negative defaults to GOMAXPROCS*4", shards: -1, wantShards: nextPowerOfTwo(defaultShardCount())},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache, err := NewSharded[int, int](4, lruFactory, tt.shards)
+			if err != nil {
+				t.Fatalf("NewSharded() error = %v", err)
+			}
+			if len(cache.shards) != tt.wantShards {
+				t.Errorf("len(shards) = %d, want %d", len(cache.shards), tt.wantShards)
+			}
+		})
+	}
+}
+
+func TestNewSharded_PropagatesFactoryError(t *testing.T) {
+	_, err := NewSharded[int, int](0, lruFactory, 4)
+	if err == nil {
+		t.Error("expected an error from a factory call with a zero capacity")
+	}
+}
+
+func TestCache_AddGetRemove(t *testing.T) {
+	cache, _ := NewSharded[int, int](4, lruFactory, 4)
+
+	if evicted, rewritten := cache.Add(1, 11); evicted || rewritten {
+		t.Errorf("Add() = %v, %v, want false, false", evicted, rewritten)
+	}
+	if value, found := cache.Get(1); !found || value != 11 {
+		t.Errorf("Get(1) = %v, %v, want 11, true", value, found)
+	}
+	if !cache.Remove(1) {
+		t.Error("Remove(1) should return true for an existing key")
+	}
+	if _, found := cache.Get(1); found {
+		t.Error("Get(1) should miss after Remove")
+	}
+}
+
+func TestCache_Len(t *testing.T) {
+	cache, _ := NewSharded[int, int](100, lruFactory, 4)
+
+	for i := 0; i < 10; i++ {
+		cache.Add(i, i)
+	}
+
+	if got := cache.Len(); got != 10 {
+		t.Errorf("Len() = %d, want 10", got)
+	}
+}
+
+func TestCache_RoutesSameKeyToSameShard(t *testing.T) {
+	cache, _ := NewSharded[int, int](4, lruFactory, 8)
+
+	first := cache.hasher(42) & cache.mask
+	for i := 0; i < 100; i++ {
+		if got := cache.hasher(42) & cache.mask; got != first {
+			t.Fatalf("hashing key 42 is not stable: got shard %d, want %d", got, first)
+		}
+	}
+}
+
+func TestCache_WithHasher(t *testing.T) {
+	cache, _ := NewShardedWithOptions[int, int](4, lruFactory, 4, WithHasher[int, int](func(key int) uint64 {
+		return 0
+	}))
+
+	cache.Add(1, 1)
+	cache.Add(2, 2)
+
+	if got := cache.Len(); got != 2 {
+		t.Errorf("Len() = %d, want 2", got)
+	}
+	if cache.shards[0].(*lru.Cache[int, int]).Len() != 2 {
+		t.Error("a constant hasher should route every key to shard 0")
+	}
+}
+
+func TestCache_Concurrent(t *testing.T) {
+	cache, _ := NewSharded[int, int](100, lruFactory, 8)
+	done := make(chan bool)
+
+	go func() {
+		for i := 0; i < 200; i++ {
+			cache.Add(i%50, i)
+		}
+		done <- true
+	}()
+
+	go func() {
+		for i := 0; i < 200; i++ {
+			cache.Get(i % 50)
+		}
+		done <- true
+	}()
+
+	go func() {
+		for i := 0; i < 100; i++ {
+			cache.Remove(i % 50)
+		}
+		done <- true
+	}()
+
+	for i := 0; i < 3; i++ {
+		<-done
+	}
+}
+
+func BenchmarkCache_SingleLock(b *testing.B) {
+	cache, _ := lru.NewCache[int, int](10000)
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			cache.Add(i%10000, i)
+			cache.Get(i % 10000)
+			i++
+		}
+	})
+}
+
+func BenchmarkCache_Sharded(b *testing.B) {
+	cache, _ := NewSharded[int, int](10000/16, lruFactory, 16)
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			cache.Add(i%10000, i)
+			cache.Get(i % 10000)
+			i++
+		}
+	})
+}
@@ -0,0 +1,329 @@
+// Package sieve implements the SIEVE cache eviction algorithm.
+//
+// SIEVE keeps a single "visited" bit per entry instead of reordering the
+// list on every hit: Get only flips the bit, and eviction walks a "hand"
+// pointer backward from the tail, clearing visited bits until it finds an
+// unvisited entry to evict. This gives near-LRU hit ratios with O(1),
+// mutation-free reads, which suits read-heavy workloads such as DNS
+// response caching.
+package sieve
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/kolobok-kelbek/cacherno/internal/cacheutil"
+)
+
+// EvictReason identifies why an entry left the cache, for an EventEvict
+// Event or a WithOnEvict callback.
+type EvictReason = cacheutil.EvictReason
+
+const (
+	ReasonCapacity = cacheutil.ReasonCapacity
+	ReasonTTL      = cacheutil.ReasonTTL
+	ReasonManual   = cacheutil.ReasonManual
+)
+
+// EventType identifies the kind of change an Event reports.
+type EventType = cacheutil.EventType
+
+const (
+	EventInsert = cacheutil.EventInsert
+	EventEvict  = cacheutil.EventEvict
+	EventRemove = cacheutil.EventRemove
+)
+
+type entry[K comparable, V any] struct {
+	key     K
+	value   V
+	visited bool
+
+	expiresAt time.Time
+
+	prev *entry[K, V]
+	next *entry[K, V]
+}
+
+func (e *entry[K, V]) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
+}
+
+type Cache[K comparable, V any] struct {
+	data     map[K]*entry[K, V]
+	lock     sync.RWMutex
+	capacity uint
+	head     *entry[K, V]
+	tail     *entry[K, V]
+	hand     *entry[K, V]
+
+	defaultTTL time.Duration
+
+	janitorInterval time.Duration
+	janitor         cacheutil.Janitor
+
+	events cacheutil.Bus[K, V]
+}
+
+// Option configures optional behavior on a Cache constructed via
+// NewCacheWithOptions.
+type Option[K comparable, V any] func(*Cache[K, V])
+
+// WithDefaultTTL sets the expiration applied to entries added via Add (and
+// via AddWithTTL when passed a non-positive ttl). Entries never expire by
+// default.
+func WithDefaultTTL[K comparable, V any](d time.Duration) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.defaultTTL = d
+	}
+}
+
+// WithJanitor starts a background goroutine that sweeps expired entries
+// every interval. Call Close to stop it.
+func WithJanitor[K comparable, V any](interval time.Duration) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.janitorInterval = interval
+	}
+}
+
+// WithOnEvict registers a callback invoked whenever an entry is evicted.
+// It runs after the internal lock has been released, so it may safely call
+// back into the cache.
+func WithOnEvict[K comparable, V any](f func(key K, value V, reason EvictReason)) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.events.SetOnEvict(f)
+	}
+}
+
+func NewCache[K comparable, V any](capacity uint) (*Cache[K, V], error) {
+	return NewCacheWithOptions[K, V](capacity)
+}
+
+func NewCacheWithOptions[K comparable, V any](capacity uint, opts ...Option[K, V]) (*Cache[K, V], error) {
+	if capacity <= 0 {
+		return nil, errors.New("must provide a positive size")
+	}
+
+	c := &Cache[K, V]{
+		data:     make(map[K]*entry[K, V], capacity),
+		capacity: capacity,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.janitorInterval > 0 {
+		c.janitor.Start(c.janitorInterval, c.sweepExpired)
+	}
+
+	return c, nil
+}
+
+func (c *Cache[K, V]) Add(key K, value V) (evicted bool, rewritten bool) {
+	return c.addWithTTL(key, value, c.defaultTTL)
+}
+
+// AddWithTTL adds key with an expiration ttl after which it is treated as a
+// miss and transparently removed. A non-positive ttl falls back to the
+// cache's default TTL, if any.
+func (c *Cache[K, V]) AddWithTTL(key K, value V, ttl time.Duration) (evicted bool, rewritten bool) {
+	return c.addWithTTL(key, value, ttl)
+}
+
+func (c *Cache[K, V]) addWithTTL(key K, value V, ttl time.Duration) (evicted bool, rewritten bool) {
+	var events []cacheutil.Event[K, V]
+	func() {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		if ttl <= 0 {
+			ttl = c.defaultTTL
+		}
+		var expiresAt time.Time
+		if ttl > 0 {
+			expiresAt = time.Now().Add(ttl)
+		}
+
+		if node, has := c.data[key]; has {
+			node.value = value
+			node.expiresAt = expiresAt
+			node.visited = true
+			rewritten = true
+			events = append(events, cacheutil.Event[K, V]{Type: EventInsert, Key: key, Value: value})
+			return
+		}
+
+		node := &entry[K, V]{
+			key:       key,
+			value:     value,
+			expiresAt: expiresAt,
+		}
+		c.data[key] = node
+		c.addToFront(node)
+		events = append(events, cacheutil.Event[K, V]{Type: EventInsert, Key: key, Value: value})
+
+		if uint(len(c.data)) > c.capacity {
+			if victim := c.evict(); victim != nil {
+				events = append(events, cacheutil.Event[K, V]{Type: EventEvict, Key: victim.key, Value: victim.value, Reason: ReasonCapacity})
+			}
+			evicted = true
+		}
+	}()
+
+	c.events.DispatchAll(events)
+	return evicted, rewritten
+}
+
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	var events []cacheutil.Event[K, V]
+	var value V
+	var found bool
+
+	func() {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		node, has := c.data[key]
+		if !has {
+			return
+		}
+
+		if node.expired(time.Now()) {
+			if c.hand == node {
+				c.hand = node.prev
+			}
+			c.removeFromList(node)
+			delete(c.data, key)
+			events = append(events, cacheutil.Event[K, V]{Type: EventEvict, Key: key, Value: node.value, Reason: ReasonTTL})
+			return
+		}
+
+		node.visited = true
+		value, found = node.value, true
+	}()
+
+	c.events.DispatchAll(events)
+	return value, found
+}
+
+func (c *Cache[K, V]) Remove(key K) bool {
+	var events []cacheutil.Event[K, V]
+	var removed bool
+
+	func() {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		node, has := c.data[key]
+		if !has {
+			return
+		}
+		if c.hand == node {
+			c.hand = node.prev
+		}
+		c.removeFromList(node)
+		delete(c.data, key)
+		events = append(events, cacheutil.Event[K, V]{Type: EventRemove, Key: key, Value: node.value, Reason: ReasonManual})
+		removed = true
+	}()
+
+	c.events.DispatchAll(events)
+	return removed
+}
+
+// Close stops the background janitor goroutine, if one was started via
+// WithJanitor. It is safe to call more than once.
+func (c *Cache[K, V]) Close() error {
+	return c.janitor.Close()
+}
+
+func (c *Cache[K, V]) sweepExpired() {
+	now := time.Now()
+	var events []cacheutil.Event[K, V]
+
+	func() {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		for key, node := range c.data {
+			if node.expired(now) {
+				if c.hand == node {
+					c.hand = node.prev
+				}
+				c.removeFromList(node)
+				delete(c.data, key)
+				events = append(events, cacheutil.Event[K, V]{Type: EventEvict, Key: key, Value: node.value, Reason: ReasonTTL})
+			}
+		}
+	}()
+
+	c.events.DispatchAll(events)
+}
+
+// evict walks the hand backward from its current position (or the tail, if
+// the hand is unset), clearing visited bits, until it finds an unvisited
+// node to evict. The hand is left at the predecessor of the evicted node,
+// which wraps to the tail on the next call once it runs past the head.
+// evict returns the evicted entry, or nil if the cache is empty.
+func (c *Cache[K, V]) evict() *entry[K, V] {
+	curr := c.hand
+	if curr == nil {
+		curr = c.tail
+	}
+
+	for curr != nil && curr.visited {
+		curr.visited = false
+		curr = curr.prev
+		if curr == nil {
+			curr = c.tail
+		}
+	}
+
+	if curr == nil {
+		return nil
+	}
+
+	c.hand = curr.prev
+	c.removeFromList(curr)
+	delete(c.data, curr.key)
+	return curr
+}
+
+func (c *Cache[K, V]) addToFront(node *entry[K, V]) {
+	if c.head == nil {
+		c.head = node
+		c.tail = node
+		return
+	}
+	node.next = c.head
+	node.prev = nil
+	c.head.prev = node
+	c.head = node
+}
+
+func (c *Cache[K, V]) removeFromList(node *entry[K, V]) {
+	if node.prev != nil {
+		node.prev.next = node.next
+	} else {
+		c.head = node.next
+	}
+	if node.next != nil {
+		node.next.prev = node.prev
+	} else {
+		c.tail = node.prev
+	}
+}
+
+// Subscribe returns a buffered channel of insert/evict/remove events. Call
+// Unsubscribe to stop receiving events and release the channel.
+func (c *Cache[K, V]) Subscribe() <-chan cacheutil.Event[K, V] {
+	return c.events.Subscribe()
+}
+
+// Unsubscribe stops ch from receiving further events and closes it. It is
+// a no-op if ch was not returned by Subscribe or was already unsubscribed.
+func (c *Cache[K, V]) Unsubscribe(ch <-chan cacheutil.Event[K, V]) {
+	c.events.Unsubscribe(ch)
+}
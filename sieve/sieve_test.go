@@ -0,0 +1,343 @@
+package sieve
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewCache(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity uint
+		wantErr  bool
+	}{
+		{
+			name:     "valid capacity",
+			capacity: 5,
+			wantErr:  false,
+		},
+		{
+			name:     "zero capacity",
+			capacity: 0,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache, err := NewCache[string, int](tt.capacity)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewCache() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && cache == nil {
+				t.Error("NewCache() returned nil cache without error")
+			}
+		})
+	}
+}
+
+func TestCache_Add(t *testing.T) {
+	cache, _ := NewCache[string, int](2)
+
+	tests := []struct {
+		name        string
+		key         string
+		value       int
+		wantEvicted bool
+		wantRewrite bool
+	}{
+		{
+			name:        "add first item",
+			key:         "key1",
+			value:       1,
+			wantEvicted: false,
+			wantRewrite: false,
+		},
+		{
+			name:        "add second item",
+			key:         "key2",
+			value:       2,
+			wantEvicted: false,
+			wantRewrite: false,
+		},
+		{
+			name:        "add third item (causes eviction)",
+			key:         "key3",
+			value:       3,
+			wantEvicted: true,
+			wantRewrite: false,
+		},
+		{
+			name:        "rewrite existing item",
+			key:         "key2",
+			value:       22,
+			wantEvicted: false,
+			wantRewrite: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evicted, rewritten := cache.Add(tt.key, tt.value)
+			if evicted != tt.wantEvicted {
+				t.Errorf("Add() evicted = %v, want %v", evicted, tt.wantEvicted)
+			}
+			if rewritten != tt.wantRewrite {
+				t.Errorf("Add() rewritten = %v, want %v", rewritten, tt.wantRewrite)
+			}
+		})
+	}
+}
+
+func TestCache_Get(t *testing.T) {
+	cache, _ := NewCache[string, int](2)
+	cache.Add("key1", 1)
+	cache.Add("key2", 2)
+
+	tests := []struct {
+		name      string
+		key       string
+		wantValue int
+		wantFound bool
+	}{
+		{
+			name:      "get existing item",
+			key:       "key1",
+			wantValue: 1,
+			wantFound: true,
+		},
+		{
+			name:      "get non-existing item",
+			key:       "key3",
+			wantValue: 0,
+			wantFound: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, found := cache.Get(tt.key)
+			if found != tt.wantFound {
+				t.Errorf("Get() found = %v, want %v", found, tt.wantFound)
+			}
+			if value != tt.wantValue {
+				t.Errorf("Get() value = %v, want %v", value, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestCache_Remove(t *testing.T) {
+	cache, _ := NewCache[string, int](2)
+	cache.Add("key1", 1)
+
+	tests := []struct {
+		name string
+		key  string
+		want bool
+	}{
+		{
+			name: "remove existing item",
+			key:  "key1",
+			want: true,
+		},
+		{
+			name: "remove non-existing item",
+			key:  "key2",
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cache.Remove(tt.key); got != tt.want {
+				t.Errorf("Remove() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCache_VisitedSurvivesEviction(t *testing.T) {
+	cache, _ := NewCache[string, int](2)
+
+	// Add initial items
+	cache.Add("key1", 1)
+	cache.Add("key2", 2)
+
+	// Mark key1 as visited
+	cache.Get("key1")
+
+	// Add new item: the hand starts at the tail (key1), but key1 is
+	// visited so its bit is cleared and the hand moves on to key2, which
+	// gets evicted instead.
+	cache.Add("key3", 3)
+
+	if _, found := cache.Get("key2"); found {
+		t.Error("key2 should have been evicted")
+	}
+	if _, found := cache.Get("key1"); !found {
+		t.Error("key1 should still be present")
+	}
+	if _, found := cache.Get("key3"); !found {
+		t.Error("key3 should be present")
+	}
+}
+
+func TestCache_HandAdvancesOnRepeatedEviction(t *testing.T) {
+	cache, _ := NewCache[string, int](1)
+
+	cache.Add("key1", 1)
+	cache.Add("key2", 2) // evicts key1, hand left at its predecessor key2
+	cache.Add("key3", 3) // evicts key2
+
+	if _, found := cache.Get("key1"); found {
+		t.Error("key1 should have been evicted")
+	}
+	if _, found := cache.Get("key2"); found {
+		t.Error("key2 should have been evicted")
+	}
+	if _, found := cache.Get("key3"); !found {
+		t.Error("key3 should be present")
+	}
+}
+
+func TestCache_Concurrent(t *testing.T) {
+	cache, _ := NewCache[int, int](100)
+	done := make(chan bool)
+
+	// Writer goroutine
+	go func() {
+		for i := 0; i < 100; i++ {
+			cache.Add(i, i)
+		}
+		done <- true
+	}()
+
+	// Reader goroutine
+	go func() {
+		for i := 0; i < 100; i++ {
+			cache.Get(i)
+		}
+		done <- true
+	}()
+
+	// Remover goroutine
+	go func() {
+		for i := 0; i < 50; i++ {
+			cache.Remove(i)
+		}
+		done <- true
+	}()
+
+	for i := 0; i < 3; i++ {
+		<-done
+	}
+}
+
+func TestCache_AddWithTTL_Expires(t *testing.T) {
+	cache, _ := NewCache[string, int](2)
+
+	cache.AddWithTTL("key1", 1, 10*time.Millisecond)
+	if _, found := cache.Get("key1"); !found {
+		t.Fatal("key1 should be present before it expires")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if _, found := cache.Get("key1"); found {
+		t.Error("key1 should have expired")
+	}
+}
+
+func TestCache_WithDefaultTTL(t *testing.T) {
+	cache, _ := NewCacheWithOptions[string, int](2, WithDefaultTTL[string, int](10*time.Millisecond))
+
+	cache.Add("key1", 1)
+	time.Sleep(20 * time.Millisecond)
+
+	if _, found := cache.Get("key1"); found {
+		t.Error("key1 should have expired under the default TTL")
+	}
+}
+
+func TestCache_WithJanitor_SweepsExpiredEntries(t *testing.T) {
+	cache, _ := NewCacheWithOptions[string, int](2, WithJanitor[string, int](5*time.Millisecond))
+	defer cache.Close()
+
+	cache.AddWithTTL("key1", 1, 5*time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+
+	cache.lock.RLock()
+	_, has := cache.data["key1"]
+	cache.lock.RUnlock()
+
+	if has {
+		t.Error("janitor should have swept the expired entry out of the map")
+	}
+}
+
+func TestCache_Close_StopsJanitor(t *testing.T) {
+	cache, _ := NewCacheWithOptions[string, int](2, WithJanitor[string, int](5*time.Millisecond))
+
+	if err := cache.Close(); err != nil {
+		t.Errorf("Close() returned an error: %v", err)
+	}
+	if err := cache.Close(); err != nil {
+		t.Errorf("second Close() returned an error: %v", err)
+	}
+}
+
+func TestCache_WithOnEvict(t *testing.T) {
+	type evictedEntry struct {
+		key    string
+		value  int
+		reason EvictReason
+	}
+	var mu sync.Mutex
+	var evictions []evictedEntry
+
+	cache, _ := NewCacheWithOptions[string, int](2, WithOnEvict[string, int](func(key string, value int, reason EvictReason) {
+		mu.Lock()
+		defer mu.Unlock()
+		evictions = append(evictions, evictedEntry{key, value, reason})
+	}))
+
+	cache.Add("key1", 1)
+	cache.Add("key2", 2)
+	cache.Add("key3", 3) // neither key1 nor key2 is visited: evicts key1 (tail)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(evictions) != 1 || evictions[0].key != "key1" || evictions[0].value != 1 || evictions[0].reason != ReasonCapacity {
+		t.Errorf("evictions = %+v, want one capacity eviction of key1=1", evictions)
+	}
+}
+
+func TestCache_Subscribe(t *testing.T) {
+	cache, _ := NewCache[string, int](2)
+	ch := cache.Subscribe()
+	defer cache.Unsubscribe(ch)
+
+	cache.Add("key1", 1)
+	if ev := <-ch; ev.Type != EventInsert || ev.Key != "key1" || ev.Value != 1 {
+		t.Errorf("first event = %+v, want insert key1=1", ev)
+	}
+
+	cache.Remove("key1")
+	if ev := <-ch; ev.Type != EventRemove || ev.Key != "key1" {
+		t.Errorf("second event = %+v, want removal of key1", ev)
+	}
+}
+
+func TestCache_Unsubscribe(t *testing.T) {
+	cache, _ := NewCache[string, int](2)
+	sub := cache.Subscribe()
+	cache.Unsubscribe(sub)
+
+	cache.Add("key1", 1)
+	if _, open := <-sub; open {
+		t.Error("an unsubscribed channel should be closed, not receive further events")
+	}
+}
@@ -0,0 +1,554 @@
+// Package twoq implements the 2Q cache admission algorithm.
+//
+// 2Q filters one-hit-wonders out of the main cache by routing every new key
+// through a small "recent" LRU first; only a second Add promotes it into
+// "frequent", the long-lived LRU that holds the actual working set (a Get
+// leaves a "recent" entry where it is). A key-only "ghost" LRU remembers
+// keys recently evicted from "recent" so that adding such a key again
+// promotes it straight to "frequent" without having to earn its way
+// through "recent" again; a Get of a ghost key is a miss.
+package twoq
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/kolobok-kelbek/cacherno/internal/cacheutil"
+)
+
+const (
+	defaultRecentRatio = 0.25
+	defaultGhostRatio  = 0.5
+)
+
+// ErrSizeExceedCapacity is returned by AddWithTTL when a value's size, as
+// reported by a WithSizer func, exceeds the cache's WithMaxBytes budget on
+// its own.
+var ErrSizeExceedCapacity = errors.New("value size exceeds max bytes capacity")
+
+// EvictReason identifies why an entry left the cache, for an EventEvict
+// Event or a WithOnEvict callback.
+type EvictReason = cacheutil.EvictReason
+
+const (
+	ReasonCapacity = cacheutil.ReasonCapacity
+	ReasonTTL      = cacheutil.ReasonTTL
+	ReasonManual   = cacheutil.ReasonManual
+)
+
+// EventType identifies the kind of change an Event reports.
+type EventType = cacheutil.EventType
+
+const (
+	EventInsert = cacheutil.EventInsert
+	EventEvict  = cacheutil.EventEvict
+	EventRemove = cacheutil.EventRemove
+)
+
+type location uint8
+
+const (
+	locRecent location = iota
+	locFrequent
+	locGhost
+)
+
+type entry[K comparable, V any] struct {
+	key   K
+	value V
+	loc   location
+	size  int64
+
+	expiresAt time.Time
+
+	prev *entry[K, V]
+	next *entry[K, V]
+}
+
+func (e *entry[K, V]) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
+}
+
+type Cache[K comparable, V any] struct {
+	data map[K]*entry[K, V]
+	lock sync.RWMutex
+
+	capacity  uint
+	recentCap uint
+	ghostCap  uint
+
+	recentHead, recentTail *entry[K, V]
+	recentLen              uint
+
+	frequentHead, frequentTail *entry[K, V]
+	frequentLen                uint
+
+	ghostHead, ghostTail *entry[K, V]
+	ghostLen             uint
+
+	defaultTTL time.Duration
+
+	janitorInterval time.Duration
+	janitor         cacheutil.Janitor
+
+	sizer    func(V) int64
+	maxBytes int64
+	bytes    int64
+
+	events cacheutil.Bus[K, V]
+}
+
+// Option configures optional behavior on a Cache constructed via
+// NewCacheWithOptions.
+type Option[K comparable, V any] func(*Cache[K, V])
+
+// WithDefaultTTL sets the expiration applied to entries added via Add (and
+// via AddWithTTL when passed a non-positive ttl). Entries never expire by
+// default.
+func WithDefaultTTL[K comparable, V any](d time.Duration) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.defaultTTL = d
+	}
+}
+
+// WithJanitor starts a background goroutine that sweeps expired entries
+// every interval. Call Close to stop it.
+func WithJanitor[K comparable, V any](interval time.Duration) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.janitorInterval = interval
+	}
+}
+
+// WithSizer enables byte-size accounting: f reports the size of a value,
+// and that size is added to Bytes() and tracked per entry. Pair with
+// WithMaxBytes to cap the cache by total size in addition to entry count.
+func WithSizer[K comparable, V any](f func(V) int64) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.sizer = f
+	}
+}
+
+// WithMaxBytes caps the cache's total tracked size, evicting entries (in
+// the cache's normal eviction order) after each Add until the budget is
+// met. Has no effect without WithSizer.
+func WithMaxBytes[K comparable, V any](n int64) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.maxBytes = n
+	}
+}
+
+// WithOnEvict registers a callback invoked whenever an entry is evicted.
+// It runs after the internal lock has been released, so it may safely call
+// back into the cache.
+func WithOnEvict[K comparable, V any](f func(key K, value V, reason EvictReason)) Option[K, V] {
+	return func(c *Cache[K, V]) {
+		c.events.SetOnEvict(f)
+	}
+}
+
+func NewCache[K comparable, V any](capacity uint) (*Cache[K, V], error) {
+	return NewCacheWithRatios[K, V](capacity, defaultRecentRatio, defaultGhostRatio)
+}
+
+func NewCacheWithRatios[K comparable, V any](capacity uint, recentRatio, ghostRatio float64, opts ...Option[K, V]) (*Cache[K, V], error) {
+	if capacity <= 0 {
+		return nil, errors.New("must provide a positive size")
+	}
+	if recentRatio <= 0 || recentRatio >= 1 {
+		return nil, errors.New("recentRatio must be between 0 and 1")
+	}
+	if ghostRatio < 0 {
+		return nil, errors.New("ghostRatio must not be negative")
+	}
+
+	recentCap := uint(float64(capacity) * recentRatio)
+	if recentCap == 0 {
+		recentCap = 1
+	}
+	if recentCap >= capacity {
+		recentCap = capacity - 1
+	}
+	if recentCap == 0 {
+		recentCap = 1
+	}
+
+	c := &Cache[K, V]{
+		data:      make(map[K]*entry[K, V], capacity),
+		capacity:  capacity,
+		recentCap: recentCap,
+		ghostCap:  uint(float64(capacity) * ghostRatio),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.janitorInterval > 0 {
+		c.janitor.Start(c.janitorInterval, c.sweepExpired)
+	}
+
+	return c, nil
+}
+
+// NewCacheWithOptions builds a 2Q cache with the default recent/ghost
+// ratios and the given options.
+func NewCacheWithOptions[K comparable, V any](capacity uint, opts ...Option[K, V]) (*Cache[K, V], error) {
+	return NewCacheWithRatios[K, V](capacity, defaultRecentRatio, defaultGhostRatio, opts...)
+}
+
+func (c *Cache[K, V]) Add(key K, value V) (evicted bool, rewritten bool) {
+	evicted, rewritten, _ = c.addWithTTL(key, value, c.defaultTTL)
+	return
+}
+
+// AddWithTTL adds key with an expiration ttl after which it is treated as a
+// miss and transparently removed. A non-positive ttl falls back to the
+// cache's default TTL, if any. It returns ErrSizeExceedCapacity, without
+// adding the entry, when a WithSizer/WithMaxBytes budget is configured and
+// value alone exceeds it.
+func (c *Cache[K, V]) AddWithTTL(key K, value V, ttl time.Duration) (evicted bool, rewritten bool, err error) {
+	return c.addWithTTL(key, value, ttl)
+}
+
+func (c *Cache[K, V]) addWithTTL(key K, value V, ttl time.Duration) (evicted bool, rewritten bool, err error) {
+	var events []cacheutil.Event[K, V]
+	func() {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		var size int64
+		if c.sizer != nil {
+			size = c.sizer(value)
+			if c.maxBytes > 0 && size > c.maxBytes {
+				err = ErrSizeExceedCapacity
+				return
+			}
+		}
+
+		if ttl <= 0 {
+			ttl = c.defaultTTL
+		}
+		var expiresAt time.Time
+		if ttl > 0 {
+			expiresAt = time.Now().Add(ttl)
+		}
+
+		if node, has := c.data[key]; has {
+			switch node.loc {
+			case locFrequent:
+				c.bytes += size - node.size
+				node.value = value
+				node.size = size
+				node.expiresAt = expiresAt
+				c.unlink(&c.frequentHead, &c.frequentTail, node)
+				c.pushFront(&c.frequentHead, &c.frequentTail, node)
+				rewritten = true
+				events = append(events, cacheutil.Event[K, V]{Type: EventInsert, Key: key, Value: value})
+				evicted = c.evictOverBudget(&events)
+				return
+
+			case locRecent:
+				c.unlink(&c.recentHead, &c.recentTail, node)
+				c.recentLen--
+				c.bytes += size - node.size
+				node.value = value
+				node.size = size
+				node.expiresAt = expiresAt
+				node.loc = locFrequent
+				c.pushFront(&c.frequentHead, &c.frequentTail, node)
+				c.frequentLen++
+				rewritten = true
+				events = append(events, cacheutil.Event[K, V]{Type: EventInsert, Key: key, Value: value})
+				evicted = c.maybeEvictFrequent(&events)
+				if c.evictOverBudget(&events) {
+					evicted = true
+				}
+				return
+
+			case locGhost:
+				c.unlink(&c.ghostHead, &c.ghostTail, node)
+				c.ghostLen--
+				node.value = value
+				node.size = size
+				node.expiresAt = expiresAt
+				node.loc = locFrequent
+				c.pushFront(&c.frequentHead, &c.frequentTail, node)
+				c.frequentLen++
+				c.bytes += size
+				events = append(events, cacheutil.Event[K, V]{Type: EventInsert, Key: key, Value: value})
+				evicted = c.maybeEvictFrequent(&events)
+				if c.evictOverBudget(&events) {
+					evicted = true
+				}
+				return
+			}
+		}
+
+		node := &entry[K, V]{key: key, value: value, loc: locRecent, size: size, expiresAt: expiresAt}
+		c.data[key] = node
+		c.pushFront(&c.recentHead, &c.recentTail, node)
+		c.recentLen++
+		c.bytes += size
+		events = append(events, cacheutil.Event[K, V]{Type: EventInsert, Key: key, Value: value})
+
+		if c.recentLen > c.recentCap {
+			c.evictRecent(&events)
+			evicted = true
+		}
+		if c.maybeEvictFrequent(&events) {
+			evicted = true
+		}
+		if c.evictOverBudget(&events) {
+			evicted = true
+		}
+	}()
+
+	c.events.DispatchAll(events)
+	return evicted, rewritten, err
+}
+
+// evictOverBudget evicts entries in 2Q's normal demotion order (recent's
+// LRU into ghost first, then frequent's LRU) while the cache is over its
+// WithMaxBytes budget, appending an EventEvict for each to events and
+// returning whether it evicted anything.
+func (c *Cache[K, V]) evictOverBudget(events *[]cacheutil.Event[K, V]) bool {
+	if c.maxBytes <= 0 {
+		return false
+	}
+
+	evicted := false
+	for c.bytes > c.maxBytes {
+		if c.recentLen > 0 {
+			c.evictRecent(events)
+			evicted = true
+			continue
+		}
+		if c.frequentLen > 0 {
+			c.evictFrequentLRU(events)
+			evicted = true
+			continue
+		}
+		break
+	}
+	return evicted
+}
+
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	var events []cacheutil.Event[K, V]
+	var value V
+	var found bool
+
+	func() {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		node, has := c.data[key]
+		if !has || node.loc == locGhost {
+			return
+		}
+
+		if node.expired(time.Now()) {
+			switch node.loc {
+			case locRecent:
+				c.unlink(&c.recentHead, &c.recentTail, node)
+				c.recentLen--
+			case locFrequent:
+				c.unlink(&c.frequentHead, &c.frequentTail, node)
+				c.frequentLen--
+			}
+			delete(c.data, key)
+			c.bytes -= node.size
+			events = append(events, cacheutil.Event[K, V]{Type: EventEvict, Key: key, Value: node.value, Reason: ReasonTTL})
+			return
+		}
+
+		if node.loc == locFrequent {
+			c.unlink(&c.frequentHead, &c.frequentTail, node)
+			c.pushFront(&c.frequentHead, &c.frequentTail, node)
+		}
+
+		value, found = node.value, true
+	}()
+
+	c.events.DispatchAll(events)
+	return value, found
+}
+
+// Close stops the background janitor goroutine, if one was started via
+// WithJanitor. It is safe to call more than once.
+func (c *Cache[K, V]) Close() error {
+	return c.janitor.Close()
+}
+
+func (c *Cache[K, V]) sweepExpired() {
+	now := time.Now()
+	var events []cacheutil.Event[K, V]
+
+	func() {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		for key, node := range c.data {
+			if node.loc == locGhost || !node.expired(now) {
+				continue
+			}
+			switch node.loc {
+			case locRecent:
+				c.unlink(&c.recentHead, &c.recentTail, node)
+				c.recentLen--
+			case locFrequent:
+				c.unlink(&c.frequentHead, &c.frequentTail, node)
+				c.frequentLen--
+			}
+			delete(c.data, key)
+			c.bytes -= node.size
+			events = append(events, cacheutil.Event[K, V]{Type: EventEvict, Key: key, Value: node.value, Reason: ReasonTTL})
+		}
+	}()
+
+	c.events.DispatchAll(events)
+}
+
+func (c *Cache[K, V]) Remove(key K) bool {
+	var events []cacheutil.Event[K, V]
+	var removed bool
+
+	func() {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		node, has := c.data[key]
+		if !has {
+			return
+		}
+
+		switch node.loc {
+		case locRecent:
+			c.unlink(&c.recentHead, &c.recentTail, node)
+			c.recentLen--
+		case locFrequent:
+			c.unlink(&c.frequentHead, &c.frequentTail, node)
+			c.frequentLen--
+		case locGhost:
+			c.unlink(&c.ghostHead, &c.ghostTail, node)
+			c.ghostLen--
+		}
+
+		delete(c.data, key)
+		c.bytes -= node.size
+		if node.loc != locGhost {
+			events = append(events, cacheutil.Event[K, V]{Type: EventRemove, Key: key, Value: node.value, Reason: ReasonManual})
+		}
+		removed = true
+	}()
+
+	c.events.DispatchAll(events)
+	return removed
+}
+
+// Len returns the number of resident entries currently cached (recent and
+// frequent; the key-only ghost list is not counted).
+func (c *Cache[K, V]) Len() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return int(c.recentLen + c.frequentLen)
+}
+
+// Bytes returns the total size of resident entries as reported by
+// WithSizer. It is always 0 without WithSizer configured.
+func (c *Cache[K, V]) Bytes() int64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.bytes
+}
+
+// evictRecent demotes the LRU entry of recent into ghost, appending an
+// EventEvict to events, and trims ghost's own oldest key (no event, since a
+// ghost entry carries no value) if that pushes it over its budget.
+func (c *Cache[K, V]) evictRecent(events *[]cacheutil.Event[K, V]) {
+	node := c.recentTail
+	if node == nil {
+		return
+	}
+	c.unlink(&c.recentHead, &c.recentTail, node)
+	c.recentLen--
+	c.bytes -= node.size
+	*events = append(*events, cacheutil.Event[K, V]{Type: EventEvict, Key: node.key, Value: node.value, Reason: ReasonCapacity})
+
+	var zero V
+	node.value = zero
+	node.size = 0
+	node.loc = locGhost
+	c.pushFront(&c.ghostHead, &c.ghostTail, node)
+	c.ghostLen++
+
+	if c.ghostLen > c.ghostCap {
+		oldest := c.ghostTail
+		c.unlink(&c.ghostHead, &c.ghostTail, oldest)
+		c.ghostLen--
+		delete(c.data, oldest.key)
+	}
+}
+
+// maybeEvictFrequent evicts frequent's LRU entry when the combined size of
+// recent and frequent has grown past capacity, returning whether it did.
+func (c *Cache[K, V]) maybeEvictFrequent(events *[]cacheutil.Event[K, V]) bool {
+	if c.recentLen+c.frequentLen <= c.capacity {
+		return false
+	}
+	return c.evictFrequentLRU(events)
+}
+
+// evictFrequentLRU unconditionally evicts frequent's LRU entry, appending
+// an EventEvict to events, and returns whether there was one to evict.
+func (c *Cache[K, V]) evictFrequentLRU(events *[]cacheutil.Event[K, V]) bool {
+	node := c.frequentTail
+	if node == nil {
+		return false
+	}
+	c.unlink(&c.frequentHead, &c.frequentTail, node)
+	c.frequentLen--
+	delete(c.data, node.key)
+	c.bytes -= node.size
+	*events = append(*events, cacheutil.Event[K, V]{Type: EventEvict, Key: node.key, Value: node.value, Reason: ReasonCapacity})
+	return true
+}
+
+func (c *Cache[K, V]) pushFront(head, tail **entry[K, V], node *entry[K, V]) {
+	node.prev = nil
+	node.next = *head
+	if *head != nil {
+		(*head).prev = node
+	} else {
+		*tail = node
+	}
+	*head = node
+}
+
+func (c *Cache[K, V]) unlink(head, tail **entry[K, V], node *entry[K, V]) {
+	if node.prev != nil {
+		node.prev.next = node.next
+	} else {
+		*head = node.next
+	}
+	if node.next != nil {
+		node.next.prev = node.prev
+	} else {
+		*tail = node.prev
+	}
+	node.prev = nil
+	node.next = nil
+}
+
+// Subscribe returns a buffered channel of insert/evict/remove events. Call
+// Unsubscribe to stop receiving events and release the channel.
+func (c *Cache[K, V]) Subscribe() <-chan cacheutil.Event[K, V] {
+	return c.events.Subscribe()
+}
+
+// Unsubscribe stops ch from receiving further events and closes it. It is
+// a no-op if ch was not returned by Subscribe or was already unsubscribed.
+func (c *Cache[K, V]) Unsubscribe(ch <-chan cacheutil.Event[K, V]) {
+	c.events.Unsubscribe(ch)
+}
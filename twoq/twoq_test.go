@@ -0,0 +1,341 @@
+package twoq
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewCache(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity uint
+		wantErr  bool
+	}{
+		{
+			name:     "valid capacity",
+			capacity: 8,
+			wantErr:  false,
+		},
+		{
+			name:     "zero capacity",
+			capacity: 0,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache, err := NewCache[string, int](tt.capacity)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewCache() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && cache == nil {
+				t.Error("NewCache() returned nil cache without error")
+			}
+		})
+	}
+}
+
+func TestNewCacheWithRatios_InvalidRatios(t *testing.T) {
+	if _, err := NewCacheWithRatios[string, int](8, 0, 0.5); err == nil {
+		t.Error("expected error for zero recentRatio")
+	}
+	if _, err := NewCacheWithRatios[string, int](8, 1, 0.5); err == nil {
+		t.Error("expected error for recentRatio >= 1")
+	}
+	if _, err := NewCacheWithRatios[string, int](8, 0.25, -1); err == nil {
+		t.Error("expected error for negative ghostRatio")
+	}
+}
+
+func TestCache_PromotionToFrequent(t *testing.T) {
+	cache, _ := NewCache[string, int](8)
+
+	cache.Add("key1", 1)
+	if _, found := cache.Get("key1"); !found {
+		t.Fatal("key1 should be present in recent")
+	}
+
+	evicted, rewritten := cache.Add("key1", 11)
+	if evicted {
+		t.Error("promoting key1 should not evict with capacity to spare")
+	}
+	if !rewritten {
+		t.Error("re-adding key1 should report rewritten")
+	}
+	if cache.recentLen != 0 {
+		t.Errorf("key1 should have left recent, recentLen = %d", cache.recentLen)
+	}
+	if cache.frequentLen != 1 {
+		t.Errorf("key1 should be in frequent, frequentLen = %d", cache.frequentLen)
+	}
+}
+
+func TestCache_GhostPromotion(t *testing.T) {
+	cache, _ := NewCacheWithRatios[string, int](4, 0.25, 1)
+
+	cache.Add("key1", 1) // fills recent (cap 1), no eviction yet
+	cache.Add("key2", 2) // evicts key1 from recent into ghost
+
+	if cache.ghostLen != 1 {
+		t.Fatalf("key1 should be in ghost, ghostLen = %d", cache.ghostLen)
+	}
+	if _, found := cache.Get("key1"); found {
+		t.Error("a ghost entry should not be a cache hit")
+	}
+
+	evicted, rewritten := cache.Add("key1", 111)
+	if rewritten {
+		t.Error("promoting from ghost is an insert, not a rewrite")
+	}
+	if evicted {
+		t.Error("promoting key1 should not evict with capacity to spare")
+	}
+	if cache.ghostLen != 0 {
+		t.Errorf("key1 should have left ghost, ghostLen = %d", cache.ghostLen)
+	}
+
+	value, found := cache.Get("key1")
+	if !found || value != 111 {
+		t.Errorf("key1 should be a frequent hit with value 111, got %v, %v", value, found)
+	}
+}
+
+func TestCache_SizeAccounting(t *testing.T) {
+	cache, _ := NewCache[string, int](2)
+
+	cache.Add("a", 1)
+	cache.Add("b", 2)  // evicts a into ghost
+	cache.Add("a", 11) // a promoted from ghost to frequent
+	cache.Add("c", 3)  // evicts b into ghost
+
+	evicted, rewritten := cache.Add("b", 22) // b promoted from ghost, recent+frequent now over capacity
+
+	if cache.recentLen+cache.frequentLen > cache.capacity {
+		t.Errorf("recent+frequent exceeded capacity: %d > %d", cache.recentLen+cache.frequentLen, cache.capacity)
+	}
+	if rewritten {
+		t.Error("promoting from ghost is an insert, not a rewrite")
+	}
+	if !evicted {
+		t.Error("promoting past capacity should report an eviction")
+	}
+}
+
+func TestCache_NewKeyRespectsCapacityOnceFrequentIsFull(t *testing.T) {
+	cache, _ := NewCache[string, int](8)
+
+	// Promote 8 distinct keys into frequent via a second Add while they sit
+	// in recent, filling frequent to capacity on its own.
+	for i := 0; i < 8; i++ {
+		key := string(rune('a' + i))
+		cache.Add(key, i)   // into recent
+		cache.Add(key, i)   // promoted from recent into frequent
+		cache.Add(key, i+1) // rewritten in frequent
+	}
+	if cache.frequentLen != 8 {
+		t.Fatalf("expected frequent full at 8, got %d", cache.frequentLen)
+	}
+
+	// A brand-new key goes into recent, which must still bound the total
+	// resident count even though recentLen alone is under recentCap.
+	cache.Add("fresh1", 100)
+	cache.Add("fresh2", 200)
+
+	if got := cache.Len(); got > 8 {
+		t.Errorf("Len() = %d, want <= capacity 8", got)
+	}
+}
+
+func TestCache_Remove(t *testing.T) {
+	cache, _ := NewCache[string, int](8)
+	cache.Add("key1", 1)
+
+	if !cache.Remove("key1") {
+		t.Error("Remove() on an existing key should return true")
+	}
+	if cache.Remove("key1") {
+		t.Error("Remove() on a missing key should return false")
+	}
+}
+
+func TestCache_Concurrent(t *testing.T) {
+	cache, _ := NewCache[int, int](100)
+	done := make(chan bool)
+
+	go func() {
+		for i := 0; i < 200; i++ {
+			cache.Add(i%50, i)
+		}
+		done <- true
+	}()
+
+	go func() {
+		for i := 0; i < 200; i++ {
+			cache.Get(i % 50)
+		}
+		done <- true
+	}()
+
+	go func() {
+		for i := 0; i < 100; i++ {
+			cache.Remove(i % 50)
+		}
+		done <- true
+	}()
+
+	for i := 0; i < 3; i++ {
+		<-done
+	}
+}
+
+func TestCache_AddWithTTL_Expires(t *testing.T) {
+	cache, _ := NewCache[string, int](8)
+
+	cache.AddWithTTL("key1", 1, 10*time.Millisecond)
+	if _, found := cache.Get("key1"); !found {
+		t.Fatal("key1 should be present before it expires")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if _, found := cache.Get("key1"); found {
+		t.Error("key1 should have expired")
+	}
+}
+
+func TestCache_WithDefaultTTL(t *testing.T) {
+	cache, _ := NewCacheWithOptions[string, int](8, WithDefaultTTL[string, int](10*time.Millisecond))
+
+	cache.Add("key1", 1)
+	time.Sleep(20 * time.Millisecond)
+
+	if _, found := cache.Get("key1"); found {
+		t.Error("key1 should have expired under the default TTL")
+	}
+}
+
+func TestCache_WithJanitor_SweepsExpiredEntries(t *testing.T) {
+	cache, _ := NewCacheWithOptions[string, int](8, WithJanitor[string, int](5*time.Millisecond))
+	defer cache.Close()
+
+	cache.AddWithTTL("key1", 1, 5*time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+
+	cache.lock.RLock()
+	_, has := cache.data["key1"]
+	cache.lock.RUnlock()
+
+	if has {
+		t.Error("janitor should have swept the expired entry out of the map")
+	}
+}
+
+func TestCache_Close_StopsJanitor(t *testing.T) {
+	cache, _ := NewCacheWithOptions[string, int](8, WithJanitor[string, int](5*time.Millisecond))
+
+	if err := cache.Close(); err != nil {
+		t.Errorf("Close() returned an error: %v", err)
+	}
+	if err := cache.Close(); err != nil {
+		t.Errorf("second Close() returned an error: %v", err)
+	}
+}
+
+func TestCache_ByteSizeEviction(t *testing.T) {
+	sizer := func(v string) int64 { return int64(len(v)) }
+	cache, _ := NewCacheWithOptions[string, string](8, WithSizer[string, string](sizer), WithMaxBytes[string, string](5))
+
+	cache.Add("key1", "abc") // 3 bytes, recent
+	cache.Add("key2", "de")  // 2 bytes, total 5, recentLen at its cap of 2
+
+	if cache.Bytes() != 5 {
+		t.Fatalf("Bytes() = %d, want 5", cache.Bytes())
+	}
+
+	evicted, _ := cache.Add("key3", "fg") // overflows recentCap: evicts key1 into ghost
+	if !evicted {
+		t.Error("adding past recentCap should report an eviction")
+	}
+	if _, found := cache.Get("key1"); found {
+		t.Error("key1 should have been demoted to ghost")
+	}
+	if cache.Bytes() > 5 {
+		t.Errorf("Bytes() = %d, should not exceed the 5 byte budget", cache.Bytes())
+	}
+}
+
+func TestCache_AddWithTTL_RejectsOversizedEntry(t *testing.T) {
+	sizer := func(v string) int64 { return int64(len(v)) }
+	cache, _ := NewCacheWithOptions[string, string](8, WithSizer[string, string](sizer), WithMaxBytes[string, string](5))
+
+	_, _, err := cache.AddWithTTL("key1", "way too big", 0)
+	if err != ErrSizeExceedCapacity {
+		t.Errorf("AddWithTTL() error = %v, want ErrSizeExceedCapacity", err)
+	}
+	if _, found := cache.Get("key1"); found {
+		t.Error("an oversized entry should not have been added")
+	}
+}
+
+func TestCache_WithOnEvict(t *testing.T) {
+	type evictedEntry struct {
+		key    string
+		value  int
+		reason EvictReason
+	}
+	var mu sync.Mutex
+	var evictions []evictedEntry
+
+	cache, _ := NewCacheWithOptions[string, int](4, WithOnEvict[string, int](func(key string, value int, reason EvictReason) {
+		mu.Lock()
+		defer mu.Unlock()
+		evictions = append(evictions, evictedEntry{key, value, reason})
+	}))
+
+	cache.Add("key1", 1) // recentCap is 1, fills recent
+	cache.Add("key2", 2) // overflows recentCap: demotes key1 into ghost
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(evictions) != 1 || evictions[0].key != "key1" || evictions[0].value != 1 || evictions[0].reason != ReasonCapacity {
+		t.Errorf("evictions = %+v, want one capacity eviction of key1=1", evictions)
+	}
+}
+
+func TestCache_Subscribe(t *testing.T) {
+	cache, _ := NewCache[string, int](4)
+	ch := cache.Subscribe()
+	defer cache.Unsubscribe(ch)
+
+	cache.Add("key1", 1)
+	if ev := <-ch; ev.Type != EventInsert || ev.Key != "key1" || ev.Value != 1 {
+		t.Errorf("first event = %+v, want insert key1=1", ev)
+	}
+
+	cache.Add("key2", 2) // overflows recentCap: demotes key1 into ghost
+	if ev := <-ch; ev.Type != EventInsert || ev.Key != "key2" {
+		t.Errorf("second event = %+v, want insert key2", ev)
+	}
+	if ev := <-ch; ev.Type != EventEvict || ev.Key != "key1" || ev.Reason != ReasonCapacity {
+		t.Errorf("third event = %+v, want capacity eviction of key1", ev)
+	}
+
+	cache.Remove("key2")
+	if ev := <-ch; ev.Type != EventRemove || ev.Key != "key2" {
+		t.Errorf("fourth event = %+v, want removal of key2", ev)
+	}
+}
+
+func TestCache_Unsubscribe(t *testing.T) {
+	cache, _ := NewCache[string, int](4)
+	sub := cache.Subscribe()
+	cache.Unsubscribe(sub)
+
+	cache.Add("key1", 1)
+	if _, open := <-sub; open {
+		t.Error("an unsubscribed channel should be closed, not receive further events")
+	}
+}